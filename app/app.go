@@ -7,6 +7,7 @@ import (
 
 	"github.com/ktr0731/evans/config"
 	"github.com/ktr0731/evans/cui"
+	"github.com/ktr0731/evans/expect"
 	"github.com/ktr0731/evans/meta"
 	"github.com/ktr0731/evans/usecase"
 	"github.com/pkg/errors"
@@ -30,6 +31,10 @@ func New(ui cui.UI) *App {
 	}
 }
 
+// exitCodeExpectationFailed is the exit code when the result of a call doesn't satisfy the expectations such as
+// --expect. It is distinguished from other errors.
+const exitCodeExpectationFailed = 2
+
 // Run starts the application. The return value means the exit code.
 func (a *App) Run(args []string) int {
 	// Currently, Evans is migrating to new-style command-line interface.
@@ -39,8 +44,8 @@ func (a *App) Run(args []string) int {
 	for _, r := range args {
 		// Hack.
 		switch r {
-		case "cli", "repl": // Sub commands for new-style interface.
-			// If an arg named "cli" or "repl" is passed, it is regarded as a sub-command of new-style.
+		case "cli", "repl", "config": // Sub commands for new-style interface.
+			// If an arg named "cli", "repl" or "config" is passed, it is regarded as a sub-command of new-style.
 			a.cmd.registerNewCommands()
 			a.cmd.RunE = nil
 		case "-h", "--help":
@@ -53,6 +58,12 @@ func (a *App) Run(args []string) int {
 		return 0
 	}
 
+	var eerr *expect.Error
+	if errors.As(err, &eerr) {
+		a.cui.Error(fmt.Sprintf("evans: %s", eerr))
+		return exitCodeExpectationFailed
+	}
+
 	var e interface {
 		Code() usecase.ErrorCode
 		Message() string
@@ -91,16 +102,23 @@ type mergedConfig struct {
 
 	// Explicit using REPL mode.
 	repl bool
+
+	// The capture file that calls are recorded to. Empty if calls are not recorded.
+	record string
 }
 
-func mergeConfig(fs *pflag.FlagSet, flags *flags, protos []string) (*mergedConfig, error) {
+func mergeConfig(fs *pflag.FlagSet, flags *flags, protos []string, requireDescriptors bool) (*mergedConfig, error) {
 	cfg, err := config.Get(fs)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get config")
 	}
 	cfg.Default.ProtoFile = append(cfg.Default.ProtoFile, protos...)
 
-	if err := cfg.Validate(); err != nil {
+	validate := cfg.Validate
+	if !requireDescriptors {
+		validate = cfg.ValidateWithoutDescriptors
+	}
+	if err := validate(); err != nil {
 		return nil, err
 	}
 
@@ -110,5 +128,6 @@ func mergeConfig(fs *pflag.FlagSet, flags *flags, protos []string) (*mergedConfi
 		file:   flags.cli.file,
 		repl:   flags.mode.repl,
 		cli:    flags.mode.cli,
+		record: flags.common.record,
 	}, nil
 }
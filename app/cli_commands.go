@@ -2,8 +2,11 @@ package app
 
 import (
 	"strings"
+	"time"
 
 	"github.com/ktr0731/evans/cui"
+	"github.com/ktr0731/evans/expect"
+	"github.com/ktr0731/evans/fill"
 	"github.com/ktr0731/evans/mode"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -14,6 +17,17 @@ func newCLICallCommand(flags *flags, ui cui.UI) *cobra.Command {
 		out          string
 		enrich       bool
 		emitDefaults bool
+		timeout      time.Duration
+		compression  string
+		template     bool
+		vars         []string
+		batch        bool
+		concurrency  int
+
+		expectCode     string
+		expectFields   []string
+		expectHeaders  []string
+		expectTrailers []string
 	)
 	cmd := &cobra.Command{
 		Use:     "call [options ...] <method>",
@@ -25,6 +39,15 @@ func newCLICallCommand(flags *flags, ui cui.UI) *cobra.Command {
 			"        $ evans -r cli call -f in.json api.Service.Unary  # call Unary method with an input file",
 			"",
 			"        $ evans -r cli call -f in.json --enrich --output json api.Service.Unary # enrich output with JSON format",
+			"        $ evans -r cli call -f in.json --timeout 3s api.Service.Unary           # cancel the call if it takes more than 3 seconds",
+			"        $ evans -r cli call -f in.json --compression zstd api.Service.Unary     # compress the request with zstd",
+			"",
+			"        $ evans -r cli call -f in.jsonl --batch -c 8 api.Service.Unary # call Unary method for each line of in.jsonl with 8 concurrent calls",
+			"",
+			`        $ evans -r cli call -f in.json --template --var id=1 api.Service.Unary # render in.json such as {"id": "{{ .id }}", "uuid": "{{ uuid }}"}`,
+			"",
+			`        $ evans -r cli call -f in.json --expect 'name == "foo"' api.Service.Unary # assert the response`,
+			"        $ evans -r cli call -f in.json --expect-code NOT_FOUND api.Service.Unary  # assert the status code",
 		}, "\n"),
 		RunE: runFunc(flags, func(cmd *cobra.Command, cfg *mergedConfig) error {
 			if cfg.REPL.ColoredOutput {
@@ -35,12 +58,49 @@ func newCLICallCommand(flags *flags, ui cui.UI) *cobra.Command {
 			if len(args) == 0 {
 				return errors.New("method is required")
 			}
+			if timeout < 0 {
+				return errors.New("--timeout must be a non-negative duration such as 10s")
+			}
+			if !cmd.Flags().Changed("timeout") {
+				timeout = cfg.Config.Request.TimeoutDuration()
+			}
+			if !cmd.Flags().Changed("compression") {
+				compression = cfg.Config.Request.Compression
+			}
+			var tmpl *fill.Template
+			if template {
+				vars, err := fill.ParseVars(vars)
+				if err != nil {
+					return err
+				}
+				tmpl = fill.NewTemplate(vars)
+			}
+			expectations, err := expect.New(expectCode, expectFields, expectHeaders, expectTrailers)
+			if err != nil {
+				return err
+			}
+			if expectations.Empty() {
+				expectations = nil
+			}
+			if concurrency < 1 {
+				return errors.New("--concurrency must be positive")
+			}
+			if batch && (expectations != nil || enrich) {
+				return errors.New("--batch cannot be used with --enrich or --expect flags")
+			}
 			invoker, err := mode.NewCallCLIInvoker(ui, args[0], &mode.CallCLIInvokerOption{
 				Headers:      cfg.Config.Request.Header,
 				Enrich:       enrich,
 				EmitDefaults: emitDefaults,
 				FilePath:     cfg.file,
 				FormatType:   out,
+				Timeout:      timeout,
+				Compression:  compression,
+				RecordFile:   cfg.record,
+				Batch:        batch,
+				Concurrency:  concurrency,
+				Template:     tmpl,
+				Expectations: expectations,
 			})
 			if err != nil {
 				return err
@@ -58,9 +118,19 @@ func newCLICallCommand(flags *flags, ui cui.UI) *cobra.Command {
 	initFlagSet(f, ui.Writer())
 	f.BoolVar(&enrich, "enrich", false, `enrich response output includes header, message, trailer and status`)
 	f.BoolVar(&emitDefaults, "emit-defaults", false, `render fields with default values`)
+	f.DurationVar(&timeout, "timeout", 0, `timeout for the RPC such as 10s. if not specified, request.timeout in the config is used`)
+	f.StringVar(&compression, "compression", "", `compress requests with gzip, zstd or snappy. if not specified, request.compression in the config is used`)
 	f.StringVarP(&out, "output", "o", "curl", `output format. one of "json" or "curl". "curl" is a curl-like format.`)
+	f.BoolVar(&batch, "batch", false, `call the unary method once for each JSON document of the input, and output the results as JSON Lines`)
+	f.IntVarP(&concurrency, "concurrency", "c", 1, `the number of concurrent calls for --batch`)
+	f.BoolVar(&template, "template", false, `render each input message as a template such as {{ uuid }} or {{ env "NAME" }}`)
+	f.StringArrayVar(&vars, "var", nil, `a variable for --template formed as key=value, referred as {{ .key }}`)
+	f.StringVar(&expectCode, "expect-code", "", `assert the status code such as NOT_FOUND. if specified, the status is not treated as an error`)
+	f.StringArrayVar(&expectFields, "expect", nil, `assert each response message by a predicate such as 'path.to.field == "x"'`)
+	f.StringArrayVar(&expectHeaders, "expect-header", nil, `assert the response header has the key and the value (key=value) or the key`)
+	f.StringArrayVar(&expectTrailers, "expect-trailer", nil, `assert the response trailer has the key and the value (key=value) or the key`)
 
-	cmd.SetHelpFunc(usageFunc(ui.Writer(), []string{"file"}))
+	cmd.SetHelpFunc(usageFunc(ui.Writer(), []string{"file", "record"}))
 	return cmd
 }
 
@@ -146,3 +216,233 @@ The symbol should be a fully-qualified name. If no symbol is passed, desc shows
 	cmd.SetHelpFunc(usageFunc(ui.Writer(), nil))
 	return cmd
 }
+
+func newCLITemplateCommand(flags *flags, ui cui.UI) *cobra.Command {
+	var (
+		out    string
+		format string
+		depth  int
+	)
+	cmd := &cobra.Command{
+		Use:   "template [options ...] <method>",
+		Short: "print an example request of a method",
+		Long: `template prints an example request of the method as JSON or YAML. The method should be a fully-qualified name.
+The "full" output fills nested messages recursively until --depth levels, adds an element to each repeated and map field and sets the first field of each oneof.
+Well-known types such as google.protobuf.Timestamp have representative values.
+The "minimal" output has only the zero values of the top-level fields.`,
+		Example: strings.Join([]string{
+			"        $ evans -r cli template api.Service.Unary               # print an example request as JSON",
+			"        $ evans -r cli template --format yaml api.Service.Unary # print an example request as YAML",
+			"        $ evans -r cli template -o minimal api.Service.Unary    # print only the top-level fields",
+		}, "\n"),
+		RunE: runFunc(flags, func(cmd *cobra.Command, cfg *mergedConfig) error {
+			if cfg.REPL.ColoredOutput {
+				ui = cui.NewColored(ui)
+			}
+
+			args := cmd.Flags().Args()
+			if len(args) == 0 {
+				return errors.New("method is required")
+			}
+			if out != "full" && out != "minimal" {
+				return errors.Errorf(`--output must be one of "full" or "minimal", but got '%s'`, out)
+			}
+			if format != "json" && format != "yaml" {
+				return errors.Errorf(`--format must be one of "json" or "yaml", but got '%s'`, format)
+			}
+			if depth < 0 {
+				return errors.New("--depth must not be negative")
+			}
+			invoker := mode.NewTemplateCLIInvoker(ui, args[0], out == "full", depth, format)
+			if err := mode.RunAsCLIMode(cfg.Config, invoker); err != nil {
+				return errors.Wrap(err, "failed to run CLI mode")
+			}
+			return nil
+		}),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	f := cmd.Flags()
+	initFlagSet(f, ui.Writer())
+	f.StringVarP(&out, "output", "o", "full", `output type. one of "full" or "minimal".`)
+	f.StringVar(&format, "format", "json", `output format. one of "json" or "yaml".`)
+	f.IntVar(&depth, "depth", 3, `the maximum depth of nested messages filled by the "full" output`)
+
+	cmd.SetHelpFunc(usageFunc(ui.Writer(), nil))
+	return cmd
+}
+
+func newCLIHealthCommand(flags *flags, ui cui.UI) *cobra.Command {
+	var watch bool
+	cmd := &cobra.Command{
+		Use:   "health [options ...] [service name]",
+		Short: "check the health of the server",
+		Long: `health checks the serving status by the gRPC health checking protocol (grpc.health.v1.Health).
+If a service name is passed, health checks the status of the service. If not, health checks the overall health of the server.
+The descriptors of the protocol are bundled, so health works without proto files and gRPC reflection.
+health exits with 0 if the status is SERVING, otherwise it exits with 1.`,
+		Example: strings.Join([]string{
+			"        $ evans cli health                     # check the overall health of the server",
+			`        $ evans cli health api.Service         # check the health of service "api.Service"`,
+			"        $ evans cli health --watch api.Service # watch the health until interrupted",
+		}, "\n"),
+		Annotations: map[string]string{bundledDescriptorsAnnotation: ""},
+		RunE: runFunc(flags, func(cmd *cobra.Command, cfg *mergedConfig) error {
+			if cfg.REPL.ColoredOutput {
+				ui = cui.NewColored(ui)
+			}
+
+			var service string
+			args := cmd.Flags().Args()
+			if len(args) > 0 {
+				service = args[0]
+			}
+
+			// The bundled descriptors are used instead of proto files and gRPC reflection.
+			cfg.Server.Reflection = false
+			cfg.Default.ProtoPath, cfg.Default.ProtoFile = nil, nil
+			cfg.Default.Package, cfg.Default.Service = "", ""
+
+			invoker := mode.NewHealthCLIInvoker(ui, service, watch)
+			return mode.RunAsCLIMode(cfg.Config, invoker)
+		}),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	f := cmd.Flags()
+	initFlagSet(f, ui.Writer())
+	f.BoolVarP(&watch, "watch", "w", false, "watch status changes until the server closes the stream or interrupted")
+
+	cmd.SetHelpFunc(usageFunc(ui.Writer(), nil))
+	return cmd
+}
+
+func newCLIBenchCommand(flags *flags, ui cui.UI) *cobra.Command {
+	var (
+		out         string
+		concurrency int
+		total       int
+		duration    time.Duration
+		rps         int
+		timeout     time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:   "bench [options ...] <method>",
+		Short: "benchmark a method",
+		Long: `bench calls a method repeatedly with the same input as call, and reports the throughput, latencies and status codes.
+Unary and server streaming methods use the first message of the input.
+Client and bidi streaming methods send all messages of the input in each call.`,
+		Example: strings.Join([]string{
+			"        $ echo '{}' | evans -r cli bench api.Service.Unary                         # call Unary method 200 times by 10 workers",
+			"        $ evans -r cli bench -f in.json -c 50 -n 10000 api.Service.Unary           # call Unary method 10000 times by 50 workers",
+			"        $ evans -r cli bench -f in.json --duration 30s --rps 100 api.Service.Unary # call Unary method 100 times per second for 30 seconds",
+			"        $ evans -r cli bench -f in.json -o json api.Service.Unary                  # report with JSON format",
+		}, "\n"),
+		RunE: runFunc(flags, func(cmd *cobra.Command, cfg *mergedConfig) error {
+			if cfg.REPL.ColoredOutput {
+				ui = cui.NewColored(ui)
+			}
+
+			args := cmd.Flags().Args()
+			if len(args) == 0 {
+				return errors.New("method is required")
+			}
+			if concurrency < 1 || total < 0 || rps < 0 {
+				return errors.New("--concurrency must be positive, and --total and --rps must not be negative")
+			}
+			// --total is the upper limit if --duration is specified.
+			if cmd.Flags().Changed("duration") && !cmd.Flags().Changed("total") {
+				total = 0
+			}
+			if timeout < 0 {
+				return errors.New("--timeout must be a non-negative duration such as 10s")
+			}
+			if !cmd.Flags().Changed("timeout") {
+				timeout = cfg.Config.Request.TimeoutDuration()
+			}
+			invoker, err := mode.NewBenchCLIInvoker(ui, args[0], &mode.BenchCLIInvokerOption{
+				Headers:     cfg.Config.Request.Header,
+				FilePath:    cfg.file,
+				FormatType:  out,
+				Timeout:     timeout,
+				Compression: cfg.Config.Request.Compression,
+				Concurrency: concurrency,
+				Total:       total,
+				Duration:    duration,
+				RPS:         rps,
+			})
+			if err != nil {
+				return err
+			}
+			if err := mode.RunAsCLIMode(cfg.Config, invoker); err != nil {
+				return errors.Wrap(err, "failed to run CLI mode")
+			}
+			return nil
+		}),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	f := cmd.Flags()
+	initFlagSet(f, ui.Writer())
+	f.IntVarP(&concurrency, "concurrency", "c", 10, `the number of workers that call the method concurrently`)
+	f.IntVarP(&total, "total", "n", 200, `the number of calls. if --duration is specified, it is unlimited by default`)
+	f.DurationVar(&duration, "duration", 0, `the duration of the benchmark such as 30s`)
+	f.IntVar(&rps, "rps", 0, `the maximum number of calls per second. 0 means no rate limit`)
+	f.DurationVar(&timeout, "timeout", 0, `timeout for each call such as 10s. if not specified, request.timeout in the config is used`)
+	f.StringVarP(&out, "output", "o", "text", `output format. one of "text" or "json".`)
+
+	cmd.SetHelpFunc(usageFunc(ui.Writer(), []string{"file"}))
+	return cmd
+}
+
+func newCLIReplayCommand(flags *flags, ui cui.UI) *cobra.Command {
+	var timeout time.Duration
+	cmd := &cobra.Command{
+		Use:   "replay [options ...] <capture file>",
+		Short: "replay recorded calls",
+		Long: `replay re-issues the calls recorded by --record, and reports differences between the records and the responses.
+The status and the response messages are compared. Headers and trailers are not compared.
+replay exits with 1 if one or more calls differ from the records.`,
+		Example: strings.Join([]string{
+			"        $ evans -r --record session.jsonl cli call -f in.json api.Service.Unary # record a call",
+			"        $ evans -r --host staging.example.com cli replay session.jsonl           # replay the calls against another server",
+		}, "\n"),
+		RunE: runFunc(flags, func(cmd *cobra.Command, cfg *mergedConfig) error {
+			if cfg.REPL.ColoredOutput {
+				ui = cui.NewColored(ui)
+			}
+
+			args := cmd.Flags().Args()
+			if len(args) == 0 {
+				return errors.New("capture file is required")
+			}
+			if timeout < 0 {
+				return errors.New("--timeout must be a non-negative duration such as 10s")
+			}
+			if !cmd.Flags().Changed("timeout") {
+				timeout = cfg.Config.Request.TimeoutDuration()
+			}
+			invoker, err := mode.NewReplayCLIInvoker(ui, args[0], &mode.ReplayCLIInvokerOption{
+				Headers:     cfg.Config.Request.Header,
+				Timeout:     timeout,
+				Compression: cfg.Config.Request.Compression,
+			})
+			if err != nil {
+				return err
+			}
+			return mode.RunAsCLIMode(cfg.Config, invoker)
+		}),
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+
+	f := cmd.Flags()
+	initFlagSet(f, ui.Writer())
+	f.DurationVar(&timeout, "timeout", 0, `timeout for each call such as 10s. if not specified, request.timeout in the config is used`)
+
+	cmd.SetHelpFunc(usageFunc(ui.Writer(), nil))
+	return cmd
+}
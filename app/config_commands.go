@@ -0,0 +1,78 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ktr0731/evans/config"
+	"github.com/ktr0731/evans/cui"
+	"github.com/ktr0731/evans/logger"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func newConfigCommand(flags *flags, ui cui.UI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "config operations",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			printUsage(cmd)
+			return nil
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	initFlagSet(cmd.Flags(), ui.Writer())
+	cmd.SetHelpFunc(usageFunc(ui.Writer(), nil))
+	cmd.AddCommand(
+		newConfigProfilesCommand(flags, ui),
+	)
+	return cmd
+}
+
+func newConfigProfilesCommand(flags *flags, ui cui.UI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "profiles",
+		Short: "list profiles",
+		Long: `profiles lists profiles defined in the global and project local config files with their server addresses.
+A profile is selected by --profile.`,
+		Example: strings.Join([]string{
+			"        $ evans config profiles",
+			"        $ evans --profile staging repl",
+		}, "\n"),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if flags.meta.verbose {
+				logger.SetOutput(os.Stderr)
+			}
+
+			cfg, err := config.Get(cmd.Flags())
+			if err != nil {
+				return errors.Wrap(err, "failed to get config")
+			}
+
+			names := make([]string, 0, len(cfg.Profiles))
+			for name := range cfg.Profiles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			w := tabwriter.NewWriter(ui.Writer(), 0, 8, 8, ' ', tabwriter.TabIndent)
+			for _, name := range names {
+				c, err := cfg.ApplyProfile(name)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(w, "%s\t%s\n", name, c.Server.Addr())
+			}
+			return w.Flush()
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	initFlagSet(cmd.Flags(), ui.Writer())
+	cmd.SetHelpFunc(usageFunc(ui.Writer(), nil))
+	return cmd
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/ktr0731/go-multierror"
 	"github.com/pkg/errors"
@@ -32,16 +33,38 @@ type flags struct {
 		service    string
 		path       []string
 		proto      []string
+		profile    string
 		host       string
 		port       string
+		target     string
+		addresses  []string
+		lbPolicy   string
 		header     map[string][]string
 		web        bool
+		connect    bool
 		reflection bool
 		tls        bool
 		cacert     string
 		cert       string
 		certKey    string
 		serverName string
+		proxy      string
+		record     string
+
+		insecureSkipVerify bool
+		pinSHA256          []string
+		tlsMinVersion      string
+	}
+
+	transport struct {
+		dialTimeout           time.Duration
+		maxSendMsgSize        int
+		maxRecvMsgSize        int
+		keepaliveTime         time.Duration
+		keepaliveTimeout      time.Duration
+		initialWindowSize     int32
+		initialConnWindowSize int32
+		userAgentSuffix       string
 	}
 
 	meta struct {
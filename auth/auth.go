@@ -0,0 +1,104 @@
+// Package auth provides credentials providers which attach tokens to each request.
+// Tokens are cached and refreshed when they are expired.
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/credentials"
+)
+
+// Provider provides tokens as the authorization header. It implements credentials.PerRPCCredentials.
+type Provider interface {
+	credentials.PerRPCCredentials
+
+	// Name returns the provider name such as "oauth2".
+	Name() string
+	// Source returns where tokens come from such as the token URL, the key file or the command.
+	Source() string
+	// Expiry returns the expiry of the cached token. It returns the zero value if no tokens are cached
+	// or the cached token never expires.
+	Expiry() time.Time
+}
+
+// tokenFunc issues a new token.
+type tokenFunc func(ctx context.Context) (*oauth2.Token, error)
+
+// provider is the common implementation of Provider. It caches tokens issued by fetch.
+type provider struct {
+	name, source string
+	fetch        tokenFunc
+
+	mu  sync.Mutex
+	tok *oauth2.Token
+}
+
+func newProvider(name, source string, fetch tokenFunc) *provider {
+	return &provider{name: name, source: source, fetch: fetch}
+}
+
+func (p *provider) Name() string {
+	return p.name
+}
+
+func (p *provider) Source() string {
+	return p.source
+}
+
+func (p *provider) Expiry() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tok == nil {
+		return time.Time{}
+	}
+	return p.tok.Expiry
+}
+
+// token returns the cached token. A new token is issued if the cached one is expired or will be expired soon.
+func (p *provider) token(ctx context.Context) (*oauth2.Token, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tok.Valid() {
+		return p.tok, nil
+	}
+	tok, err := p.fetch(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get a token from %s provider", p.name)
+	}
+	if tok.AccessToken == "" {
+		return nil, errors.Errorf("%s provider returned an empty token", p.name)
+	}
+	p.tok = tok
+	return tok, nil
+}
+
+func (p *provider) GetRequestMetadata(ctx context.Context, _ ...string) (map[string]string, error) {
+	tok, err := p.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"authorization": tok.Type() + " " + tok.AccessToken}, nil
+}
+
+// RequireTransportSecurity returns true because tokens must not be sent over plaintext connections.
+// Use AllowInsecure to send tokens to servers without TLS such as local servers.
+func (p *provider) RequireTransportSecurity() bool {
+	return true
+}
+
+// AllowInsecure returns a provider which is the same as p, but it sends tokens without transport security.
+func AllowInsecure(p Provider) Provider {
+	return &insecureProvider{p}
+}
+
+type insecureProvider struct {
+	Provider
+}
+
+func (p *insecureProvider) RequireTransportSecurity() bool {
+	return false
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/oauth2"
+)
+
+func TestProvider_Cache(t *testing.T) {
+	var n int32
+	expiry := time.Now().Add(time.Hour)
+	p := newProvider("test", "test", func(context.Context) (*oauth2.Token, error) {
+		i := atomic.AddInt32(&n, 1)
+		return &oauth2.Token{AccessToken: fmt.Sprintf("token%d", i), Expiry: expiry}, nil
+	})
+
+	if !p.Expiry().IsZero() {
+		t.Errorf("Expiry must return the zero value before the first request, but got %s", p.Expiry())
+	}
+	for i := 0; i < 3; i++ {
+		md, err := p.GetRequestMetadata(context.Background())
+		if err != nil {
+			t.Fatalf("GetRequestMetadata must not return an error, but got '%s'", err)
+		}
+		if expected, actual := "Bearer token1", md["authorization"]; expected != actual {
+			t.Errorf("expected '%s', but got '%s'", expected, actual)
+		}
+	}
+	if !p.Expiry().Equal(expiry) {
+		t.Errorf("expected %s, but got %s", expiry, p.Expiry())
+	}
+
+	// Expire the cached token.
+	expiry = time.Now().Add(time.Hour)
+	p.tok.Expiry = time.Now().Add(-time.Second)
+	md, err := p.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("GetRequestMetadata must not return an error, but got '%s'", err)
+	}
+	if expected, actual := "Bearer token2", md["authorization"]; expected != actual {
+		t.Errorf("expected '%s', but got '%s'", expected, actual)
+	}
+}
+
+func TestAllowInsecure(t *testing.T) {
+	p := newProvider("test", "test", func(context.Context) (*oauth2.Token, error) {
+		return &oauth2.Token{AccessToken: "token"}, nil
+	})
+	if !p.RequireTransportSecurity() {
+		t.Error("providers must require transport security by default")
+	}
+
+	insecure := AllowInsecure(p)
+	if insecure.RequireTransportSecurity() {
+		t.Error("AllowInsecure must return a provider which doesn't require transport security")
+	}
+	md, err := insecure.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("GetRequestMetadata must not return an error, but got '%s'", err)
+	}
+	if expected, actual := "Bearer token", md["authorization"]; expected != actual {
+		t.Errorf("expected '%s', but got '%s'", expected, actual)
+	}
+}
+
+func TestNewOAuth2Provider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		id, secret, _ := r.BasicAuth()
+		if r.Form.Get("grant_type") != "client_credentials" || id != "kumiko" || secret != "oumae" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token-%s", "token_type": "Bearer", "expires_in": 3600}`, r.Form.Get("scope"))
+	}))
+	defer srv.Close()
+
+	p := NewOAuth2Provider(srv.URL, "kumiko", "oumae", []string{"read", "write"})
+	md, err := p.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("GetRequestMetadata must not return an error, but got '%s'", err)
+	}
+	if expected, actual := "Bearer token-read write", md["authorization"]; expected != actual {
+		t.Errorf("expected '%s', but got '%s'", expected, actual)
+	}
+	if d := time.Until(p.Expiry()); d < 59*time.Minute || d > time.Hour {
+		t.Errorf("the token must be expired after an hour, but got %s", p.Expiry())
+	}
+
+	p = NewOAuth2Provider(srv.URL, "kumiko", "kousaka", nil)
+	if _, err := p.GetRequestMetadata(context.Background()); err == nil {
+		t.Errorf("GetRequestMetadata must return an error if the client is unauthorized")
+	}
+}
+
+func TestNewJWTProvider(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate a key: %s", err)
+	}
+	b, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal the key: %s", err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: b}), 0600); err != nil {
+		t.Fatalf("failed to write the key file: %s", err)
+	}
+
+	p, err := NewJWTProvider(keyFile, "evans", "kumiko", "api.example.com", 10*time.Minute)
+	if err != nil {
+		t.Fatalf("NewJWTProvider must not return an error, but got '%s'", err)
+	}
+	md, err := p.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("GetRequestMetadata must not return an error, but got '%s'", err)
+	}
+
+	var claims jwt.RegisteredClaims
+	_, err = jwt.ParseWithClaims(strings.TrimPrefix(md["authorization"], "Bearer "), &claims, func(tok *jwt.Token) (interface{}, error) {
+		if tok.Method != jwt.SigningMethodES256 {
+			return nil, fmt.Errorf("unexpected signing method %s", tok.Method.Alg())
+		}
+		return &key.PublicKey, nil
+	})
+	if err != nil {
+		t.Fatalf("the token must be signed by the key, but got '%s'", err)
+	}
+	if claims.Issuer != "evans" || claims.Subject != "kumiko" || !claims.VerifyAudience("api.example.com", true) {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+	if !claims.ExpiresAt.Time.Equal(p.Expiry()) {
+		t.Errorf("expected %s, but got %s", claims.ExpiresAt.Time, p.Expiry())
+	}
+
+	if _, err := NewJWTProvider(filepath.Join(t.TempDir(), "missing.pem"), "", "", "", 0); err == nil {
+		t.Errorf("NewJWTProvider must return an error if the key file is missing")
+	}
+}
+
+func TestNewExecProvider(t *testing.T) {
+	exp := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	jwtToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		ExpiresAt: jwt.NewNumericDate(exp),
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("failed to sign a JWT: %s", err)
+	}
+
+	cases := map[string]struct {
+		command []string
+
+		expectedToken  string
+		expectedExpiry func() time.Time
+		hasErr         bool
+	}{
+		"opaque token": {
+			command:        []string{"echo", "opaque"},
+			expectedToken:  "opaque",
+			expectedExpiry: func() time.Time { return time.Now().Add(time.Minute) },
+		},
+		"JWT": {
+			command:        []string{"echo", jwtToken},
+			expectedToken:  jwtToken,
+			expectedExpiry: func() time.Time { return exp },
+		},
+		"command failed": {
+			command: []string{"false"},
+			hasErr:  true,
+		},
+		"empty token": {
+			command: []string{"true"},
+			hasErr:  true,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			p, err := NewExecProvider(c.command, time.Minute)
+			if err != nil {
+				t.Fatalf("NewExecProvider must not return an error, but got '%s'", err)
+			}
+			md, err := p.GetRequestMetadata(context.Background())
+			if c.hasErr {
+				if err == nil {
+					t.Errorf("GetRequestMetadata must return an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetRequestMetadata must not return an error, but got '%s'", err)
+			}
+			if expected, actual := "Bearer "+c.expectedToken, md["authorization"]; expected != actual {
+				t.Errorf("expected '%s', but got '%s'", expected, actual)
+			}
+			if d := c.expectedExpiry().Sub(p.Expiry()); d < 0 || d > time.Second {
+				t.Errorf("expected %s, but got %s", c.expectedExpiry(), p.Expiry())
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// defaultExecTTL is the lifetime of tokens printed by helper commands if it is unknown.
+const defaultExecTTL = 5 * time.Minute
+
+// NewExecProvider returns a Provider that runs the helper command and uses its stdout as the token.
+// If the token is a JWT that has "exp" claim, it is refreshed on the expiry. Otherwise, it is refreshed after ttl.
+// If ttl is 0, it is 5 minutes.
+func NewExecProvider(command []string, ttl time.Duration) (Provider, error) {
+	if len(command) == 0 {
+		return nil, errors.New("command must not be empty")
+	}
+	if ttl == 0 {
+		ttl = defaultExecTTL
+	}
+
+	return newProvider("exec", strings.Join(command, " "), func(ctx context.Context) (*oauth2.Token, error) {
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, errors.Wrapf(err, "failed to run '%s': %s", command[0], strings.TrimSpace(stderr.String()))
+		}
+		tok := strings.TrimSpace(stdout.String())
+		return &oauth2.Token{AccessToken: tok, TokenType: "Bearer", Expiry: tokenExpiry(tok, ttl)}, nil
+	}), nil
+}
+
+// tokenExpiry returns the expiry of tok. If tok is not a JWT or doesn't have "exp" claim, it returns now + ttl.
+func tokenExpiry(tok string, ttl time.Duration) time.Time {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(tok, &claims); err == nil && claims.ExpiresAt != nil {
+		return claims.ExpiresAt.Time
+	}
+	return time.Now().Add(ttl)
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// defaultJWTTTL is the lifetime of signed JWTs if it is not specified.
+const defaultJWTTTL = time.Hour
+
+// NewJWTProvider returns a Provider that signs JWTs by the private key read from keyFile.
+// The signing algorithm is RS256, ES256, ES384, ES512 or EdDSA according to the key type.
+// Empty claims are omitted. If ttl is 0, JWTs are valid for an hour.
+func NewJWTProvider(keyFile, issuer, subject, audience string, ttl time.Duration) (Provider, error) {
+	b, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the key file")
+	}
+	key, err := parsePrivateKey(b)
+	if err != nil {
+		return nil, err
+	}
+	method, err := signingMethod(key)
+	if err != nil {
+		return nil, err
+	}
+	if ttl == 0 {
+		ttl = defaultJWTTTL
+	}
+
+	return newProvider("jwt", keyFile, func(context.Context) (*oauth2.Token, error) {
+		now := time.Now()
+		claims := jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		}
+		if audience != "" {
+			claims.Audience = jwt.ClaimStrings{audience}
+		}
+		s, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to sign a JWT")
+		}
+		return &oauth2.Token{AccessToken: s, TokenType: "Bearer", Expiry: claims.ExpiresAt.Time}, nil
+	}), nil
+}
+
+// parsePrivateKey parses a PEM encoded private key formed PKCS #8, PKCS #1 or SEC 1.
+func parsePrivateKey(b []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("the key file must be PEM encoded")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported private key, it must be RSA, ECDSA or Ed25519 key")
+}
+
+func signingMethod(key crypto.PrivateKey) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+		return nil, errors.Errorf("unsupported elliptic curve '%s'", k.Curve.Params().Name)
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, errors.Errorf("unsupported private key type %T", key)
+	}
+}
@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"context"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// NewOAuth2Provider returns a Provider that issues tokens by OAuth2 client credentials flow against tokenURL.
+func NewOAuth2Provider(tokenURL, clientID, clientSecret string, scopes []string) Provider {
+	cfg := &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenURL,
+		Scopes:       scopes,
+	}
+	return newProvider("oauth2", tokenURL, func(ctx context.Context) (*oauth2.Token, error) {
+		return cfg.Token(ctx)
+	})
+}
@@ -28,11 +28,30 @@ func (i UpdateInfo) UpdateAvailable() bool {
 	return i.LatestVersion != ""
 }
 
+// PreviousRequest is the requests of the last call of a method sent to a server. They are reloaded for call --repeat.
+type PreviousRequest struct {
+	// Server is the address of the server.
+	Server string `toml:"server"`
+	// Method is the fully-qualified method name.
+	Method   string            `toml:"method"`
+	Messages []PreviousMessage `toml:"messages"`
+}
+
+// PreviousMessage is a request message of the last call.
+type PreviousMessage struct {
+	// Payload is the message serialized in the protobuf wire format and encoded with base64.
+	Payload string `toml:"payload"`
+	// Interval is the time from the previous message such as "1.5s".
+	Interval string `toml:"interval"`
+}
+
 // Cache represents cached items.
 type Cache struct {
 	Version        string     `toml:"version"`
 	UpdateInfo     UpdateInfo `toml:"updateInfo"`
 	CommandHistory []string   `default:"" toml:"commandHistory"`
+	// PreviousRequests are the requests of the last calls for each server and method.
+	PreviousRequests []PreviousRequest `toml:"previousRequests"`
 
 	// SaveFunc is for testing. It will be ignored if it is nil.
 	SaveFunc func() error `toml:"-"`
@@ -47,7 +66,7 @@ func (c *Cache) Save() error {
 
 	p := resolvePath()
 
-	f, err := os.Create(p)
+	f, err := createFile(p)
 	if err != nil {
 		return err
 	}
@@ -106,10 +125,10 @@ func resolvePath() string {
 // initCacheFile creates or overwrites a new cache file with default values.
 // If directories of the file are not found, initCacheFile also creates it.
 func initCacheFile(p string) error {
-	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
 		return err
 	}
-	f, err := os.Create(p)
+	f, err := createFile(p)
 	if err != nil {
 		return err
 	}
@@ -118,3 +137,17 @@ func initCacheFile(p string) error {
 		Version: meta.Version.String(),
 	})
 }
+
+// createFile creates or truncates the cache file p. The file is readable only by the owner because it contains
+// request payloads. The mode of an existing file created by old versions is also changed.
+func createFile(p string) (*os.File, error) {
+	f, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
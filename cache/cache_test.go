@@ -52,4 +52,24 @@ func TestCache(t *testing.T) {
 			t.Fatalf("must not return an error, but got '%s'", err)
 		}
 	})
+
+	t.Run("Save restricts the permission of an existing file", func(t *testing.T) {
+		if _, err := Get(); err != nil {
+			t.Fatalf("Get must not return an error, but got '%s'", err)
+		}
+		p := resolvePath()
+		if err := os.Chmod(p, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := (&Cache{}).Save(); err != nil {
+			t.Fatalf("must not return an error, but got '%s'", err)
+		}
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := fi.Mode().Perm(); perm != 0o600 {
+			t.Errorf("the cache file must be readable only by the owner, but got %s", perm)
+		}
+	})
 }
@@ -11,6 +11,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/ktr0731/evans/logger"
@@ -293,6 +294,38 @@ func TestLoad(t *testing.T) {
 		return cfg
 	})
 
+	assertWithGolden(t, "apply a profile", func(t *testing.T) *Config {
+		oldCWD := getWorkDir(t)
+
+		cwd, cfgDir, cleanup := setupEnv(t)
+		defer cleanup()
+
+		projDir := filepath.Join(cwd, "local")
+		mkdir(t, projDir)
+
+		copyFile(t, filepath.Join(cfgDir, "config.toml"), filepath.Join(oldCWD, "testdata", "global.toml"))
+		// profiles.toml has staging and production profiles.
+		copyFile(t, filepath.Join(projDir, ".evans.toml"), filepath.Join(oldCWD, "testdata", "profiles.toml"))
+
+		mustChdir(t, projDir)
+		err := exec.Command("git", "init").Run()
+		if err != nil {
+			t.Fatalf("failed to init a pseudo project: %s", err)
+		}
+
+		fs := pflag.NewFlagSet("test", pflag.ExitOnError)
+		fs.String("profile", "", "")
+		fs.String("port", "", "")
+		// --port flag has priority over the profile.
+		_ = fs.Parse([]string{"--profile", "staging", "--port", "8443"})
+
+		cfg := mustGet(t, fs)
+
+		checkValues(t, cfg)
+
+		return cfg
+	})
+
 	assertWithGolden(t, "apply some proto files and paths", func(t *testing.T) *Config {
 		_, _, cleanup := setupEnv(t)
 		defer cleanup()
@@ -307,6 +340,83 @@ func TestLoad(t *testing.T) {
 
 		return cfg
 	})
+
+	assertWithGolden(t, "apply transport flags", func(t *testing.T) *Config {
+		_, _, cleanup := setupEnv(t)
+		defer cleanup()
+
+		fs := pflag.NewFlagSet("test", pflag.ExitOnError)
+		fs.Duration("dial-timeout", 7*time.Second, "")
+		fs.Int("max-recv-msg-size", 0, "")
+		fs.Duration("keepalive-time", 0, "")
+		fs.Int32("initial-window-size", 0, "")
+		fs.String("user-agent-suffix", "", "")
+		_ = fs.Parse([]string{
+			"--dial-timeout", "1m",
+			"--max-recv-msg-size", "16777216",
+			"--keepalive-time", "30s",
+			"--initial-window-size", "1048576",
+			"--user-agent-suffix", "reporter/1.0",
+		})
+
+		cfg := mustGet(t, fs)
+
+		checkValues(t, cfg)
+
+		return cfg
+	})
+}
+
+func TestLoad_unknownProfile(t *testing.T) {
+	_, _, cleanup := setupEnv(t)
+	defer cleanup()
+
+	fs := pflag.NewFlagSet("test", pflag.ExitOnError)
+	fs.String("profile", "", "")
+	_ = fs.Parse([]string{"--profile", "staging"})
+
+	if _, err := Get(fs); err == nil {
+		t.Errorf("Get must return an error if the profile is not found")
+	}
+}
+
+func TestConfig_ApplyProfile(t *testing.T) {
+	cfg := &Config{
+		Default: &Default{Package: "api"},
+		Server:  &Server{Host: "localhost", Port: "3333", Reflection: true},
+		Request: &Request{Header: Header{"grpc-client": []string{"evans"}}},
+		Profiles: map[string]Profile{
+			"staging": {
+				"server":  map[string]interface{}{"host": "staging.example.com", "tls": true},
+				"request": map[string]interface{}{"header": map[string]interface{}{"env": "staging"}},
+			},
+		},
+	}
+
+	actual, err := cfg.ApplyProfile("Staging")
+	if err != nil {
+		t.Fatalf("ApplyProfile must not return an error, but got '%s'", err)
+	}
+	expectedServer := &Server{Host: "staging.example.com", Port: "3333", Reflection: true, TLS: true}
+	if diff := cmp.Diff(expectedServer, actual.Server); diff != "" {
+		t.Errorf("-want, +got\n%s", diff)
+	}
+	expectedHeader := Header{"grpc-client": []string{"evans"}, "env": []string{"staging"}}
+	if diff := cmp.Diff(expectedHeader, actual.Request.Header); diff != "" {
+		t.Errorf("-want, +got\n%s", diff)
+	}
+	if actual.Default.Package != "api" {
+		t.Errorf("the package must be kept, but got '%s'", actual.Default.Package)
+	}
+
+	// The original config must not be modified.
+	if cfg.Server.Host != "localhost" || len(cfg.Request.Header) != 1 {
+		t.Errorf("ApplyProfile must not modify the receiver: %+v, %+v", cfg.Server, cfg.Request)
+	}
+
+	if _, err := cfg.ApplyProfile("production"); err == nil {
+		t.Errorf("ApplyProfile must return an error if the profile is not found")
+	}
 }
 
 func TestEdit(t *testing.T) {
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"regexp"
 	"strings"
 	"testing"
@@ -43,6 +44,9 @@ func TestE2E_CLI(t *testing.T) {
 		// Register a service that has no package.
 		registerEmptyPackageService bool
 
+		// The server implements only the gRPC health checking protocol. See startHealthServer.
+		health bool
+
 		// beforeTest set up a testcase specific environment.
 		// If beforeTest is nil, it is ignored.
 		// beforeTest may return a function named afterTest that cleans up
@@ -52,6 +56,9 @@ func TestE2E_CLI(t *testing.T) {
 		// assertTest checks whether the output is expected.
 		// If nil, it will be ignored.
 		assertTest func(t *testing.T, output string)
+		// assertErrTest checks whether the error output is expected.
+		// If nil, the error output must be empty when expectedCode is 0.
+		assertErrTest func(t *testing.T, errOutput string)
 
 		// The output we expected. It is ignored if expectedCode isn't 0.
 		expectedOut string
@@ -92,10 +99,6 @@ func TestE2E_CLI(t *testing.T) {
 			args:         "--cli --repl",
 			expectedCode: 1,
 		},
-		"cannot specify both of --tls and --web": {
-			args:         "--web --tls testdata/test.proto",
-			expectedCode: 1,
-		},
 		"cannot launch without proto files and reflection": {
 			args:         "",
 			expectedCode: 1,
@@ -220,6 +223,69 @@ func TestE2E_CLI(t *testing.T) {
 			args:        "--file testdata/client_streaming.in api.Example.ClientStreaming",
 			expectedOut: `{ "message": "you sent requests 4 times (oumae, kousaka, kawashima, kato)." }`,
 		},
+		"call client streaming RPC with a templated input": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "call",
+			args:        "--file testdata/client_streaming_template.in --template --var first=oumae api.Example.ClientStreaming",
+			beforeTest: func(t *testing.T) func(*testing.T) {
+				t.Setenv("EVANS_E2E_NAME", "kousaka")
+				return nil
+			},
+			expectedOut: `{ "message": "you sent requests 2 times (oumae, kousaka)." }`,
+		},
+		"call fails if a variable of the template is missing": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--file testdata/client_streaming_template.in --template api.Example.ClientStreaming",
+			expectedCode: 1,
+		},
+		"call unary RPC in batch": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "call",
+			args:        "--file testdata/batch.in --batch api.Example.Unary",
+			unflatten:   true,
+			expectedOut: `{"index":0,"status":{"code":"OK"},"response":{"message":"oumae"}}
+{"index":1,"status":{"code":"OK"},"response":{"message":"kousaka"}}
+{"index":2,"status":{"code":"OK"},"response":{"message":"kawashima"}}
+`,
+			assertErrTest: func(t *testing.T, errOutput string) {
+				if !strings.Contains(errOutput, "3 calls finished in") || !strings.Contains(errOutput, "(OK: 3)") {
+					t.Errorf("unexpected summary: '%s'", errOutput)
+				}
+			},
+		},
+		"call unary RPC in batch concurrently": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "call",
+			args:        "--file testdata/batch.in --batch --concurrency 3 api.Example.Unary",
+			unflatten:   true,
+			assertTest: func(t *testing.T, output string) {
+				for i, name := range []string{"oumae", "kousaka", "kawashima"} {
+					line := fmt.Sprintf(`{"index":%d,"status":{"code":"OK"},"response":{"message":"%s"}}`, i, name)
+					if !strings.Contains(output, line) {
+						t.Errorf("the output must contain '%s', but got '%s'", line, output)
+					}
+				}
+			},
+			assertErrTest: func(t *testing.T, errOutput string) {},
+		},
+		"call fails if a call in batch failed": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--file testdata/batch.in --batch api.Example.UnaryHeaderTrailerFailure",
+			expectedCode: 1,
+			assertTest: func(t *testing.T, output string) {
+				if !strings.Contains(output, `"index":2,"status":{"code":"Internal"`) {
+					t.Errorf("the output must contain the failure of each call, but got '%s'", output)
+				}
+			},
+		},
+		"call fails if --batch is used with a streaming RPC": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--file testdata/batch.in --batch api.Example.ClientStreaming",
+			expectedCode: 1,
+		},
 		"call server streaming RPC": {
 			commonFlags: "--proto testdata/test.proto",
 			cmd:         "call",
@@ -328,6 +394,109 @@ func TestE2E_CLI(t *testing.T) {
 			},
 		},
 
+		// call command with --timeout.
+
+		"unary call timed out by --timeout": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--timeout 1ns --file testdata/unary_call.in api.Example.Unary",
+			expectedCode: 1,
+		},
+		"unary call with --timeout": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "call",
+			args:        "--timeout 1s --file testdata/unary_call.in api.Example.Unary",
+			expectedOut: `{ "message": "oumae" }`,
+		},
+		"cannot call with negative --timeout": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--timeout -1s --file testdata/unary_call.in api.Example.Unary",
+			expectedCode: 1,
+		},
+		"unary call timed out by --timeout with --enrich": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--timeout 1ns --enrich --file testdata/unary_call.in api.Example.Unary",
+			expectedCode: 1,
+			assertTest: func(t *testing.T, output string) {
+				for _, s := range []string{"code: DeadlineExceeded", "deadline exceeded: the RPC didn't finish within 1ns (elapsed: "} {
+					if !strings.Contains(output, s) {
+						t.Errorf("expected to contain '%s', but missing in '%s'", s, output)
+					}
+				}
+			},
+		},
+		"server streaming call timed out by --timeout": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--timeout 1ns --file testdata/server_streaming.in api.Example.ServerStreaming",
+			expectedCode: 1,
+		},
+		"server streaming call timed out by --timeout against to gRPC-Web server": {
+			commonFlags:  "--web --proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--timeout 1ns --file testdata/server_streaming.in api.Example.ServerStreaming",
+			web:          true,
+			expectedCode: 1,
+		},
+		"client streaming call timed out by --timeout against to gRPC-Web server": {
+			commonFlags:  "--web --proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--timeout 1ns --file testdata/client_streaming.in api.Example.ClientStreaming",
+			web:          true,
+			expectedCode: 1,
+		},
+		"bidi streaming call timed out by --timeout against to gRPC-Web server": {
+			commonFlags:  "--web --proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--timeout 1ns --file testdata/bidi_streaming.in api.Example.BidiStreaming",
+			web:          true,
+			expectedCode: 1,
+		},
+
+		// call command with --compression.
+
+		"unary call with --compression": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "call",
+			args:        "--compression zstd --file testdata/unary_call.in api.Example.Unary",
+			expectedOut: `{ "message": "oumae" }`,
+		},
+		"unary call with --compression and --enrich": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "call",
+			args:        "--compression gzip --enrich --file testdata/unary_call.in api.Example.Unary",
+			assertTest: func(t *testing.T, output string) {
+				if !strings.Contains(output, "grpc-encoding: gzip") {
+					t.Errorf("expected to contain the response encoding, but missing in '%s'", output)
+				}
+			},
+		},
+		"server streaming call with --compression and --enrich": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "call",
+			args:        "--compression snappy --enrich --file testdata/server_streaming.in api.Example.ServerStreaming",
+			assertTest: func(t *testing.T, output string) {
+				if !strings.Contains(output, "grpc-encoding: snappy") {
+					t.Errorf("expected to contain the response encoding, but missing in '%s'", output)
+				}
+			},
+		},
+		"cannot call with unknown compressor": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--compression foo --file testdata/unary_call.in api.Example.Unary",
+			expectedCode: 1,
+		},
+		"cannot call with --compression against to gRPC-Web server": {
+			commonFlags:  "--web --proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--compression gzip --file testdata/unary_call.in api.Example.Unary",
+			web:          true,
+			expectedCode: 1,
+		},
+
 		// call command with reflection
 
 		"cannot launch with reflection because method name is missing": {
@@ -420,6 +589,40 @@ func TestE2E_CLI(t *testing.T) {
 			tls:         true,
 			expectedOut: `{ "message": "oumae" }`,
 		},
+		"call unary RPC with TLS and --insecure-skip-verify": {
+			commonFlags: "--tls --insecure-skip-verify --proto testdata/test.proto",
+			cmd:         "call",
+			args:        "--file testdata/unary_call.in api.Example.Unary",
+			tls:         true,
+			expectedOut: `{ "message": "oumae" }`,
+		},
+		"cannot launch with TLS because no certificates match --pin-sha256": {
+			commonFlags:  "--tls --host localhost --cacert testdata/rootCA.pem --pin-sha256 AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA= --proto testdata/test.proto",
+			cmd:          "call",
+			args:         "--file testdata/unary_call.in api.Example.Unary",
+			tls:          true,
+			expectedCode: 1,
+		},
+		"call unary RPC with TLS and --enrich": {
+			commonFlags: "--tls --host localhost --cacert testdata/rootCA.pem --tls-min-version 1.2 --proto testdata/test.proto",
+			cmd:         "call",
+			args:        "--enrich --file testdata/unary_call.in api.Example.Unary",
+			tls:         true,
+			unflatten:   true,
+			assertTest: func(t *testing.T, output string) {
+				expectedStrings := []string{
+					"tls-version: TLS 1.3\n",
+					"tls-cipher-suite: TLS_",
+					"tls-peer-certificate: subject=",
+					`O=mkcert development certificate", expiry=2029-`,
+				}
+				for _, s := range expectedStrings {
+					if !strings.Contains(output, s) {
+						t.Errorf("output must contain '%s', but got:\n%s", s, output)
+					}
+				}
+			},
+		},
 		"cannot launch with mutual TLS auth because --certkey is missing": {
 			commonFlags:  "--tls --host localhost --cacert testdata/rootCA.pem --cert testdata/localhost.pem --proto testdata/test.proto",
 			cmd:          "call",
@@ -677,13 +880,232 @@ func TestE2E_CLI(t *testing.T) {
 			args:         "api.Foo",
 			expectedCode: 1,
 		},
+
+		// template
+
+		"print a request template of a method": {
+			commonFlags:      "--proto testdata/test.proto",
+			cmd:              "template",
+			args:             "api.Example.UnarySelf",
+			assertWithGolden: true,
+		},
+		"print a request template of a method as YAML": {
+			commonFlags:      "--proto testdata/test.proto",
+			cmd:              "template",
+			args:             "--format yaml --depth 1 api.Example.UnarySelf",
+			assertWithGolden: true,
+		},
+		"print a minimal request template of a method": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "template",
+			args:        "-o minimal api.Example.UnarySelf",
+			expectedOut: `{ "you": null }`,
+		},
+		"print a request template with reflection": {
+			commonFlags: "-r",
+			cmd:         "template",
+			args:        "api.Example.UnaryRepeatedEnum",
+			reflection:  true,
+			expectedOut: `{ "choices": [ "Choice1" ] }`,
+		},
+		"cannot print a request template of a service": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "template",
+			args:         "api.Example",
+			expectedCode: 1,
+		},
+		"cannot print a request template with unknown output": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "template",
+			args:         "-o foo api.Example.Unary",
+			expectedCode: 1,
+		},
+
+		// expectations
+
+		"call unary RPC with satisfied expectations": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "call",
+			args:        `--file testdata/unary_call.in --expect message=="oumae" --expect-code OK --expect-header content-type=application/grpc api.Example.Unary`,
+			expectedOut: `{ "message": "oumae" }`,
+		},
+		"call failure unary RPC with the expected status code": {
+			commonFlags: "-r",
+			cmd:         "call",
+			args:        "--file testdata/unary_call.in --expect-code INTERNAL --expect-trailer trailer_key1=trailer_val1 api.Example.UnaryHeaderTrailerFailure",
+			reflection:  true,
+		},
+		"call unary RPC with unsatisfied expectations": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "call",
+			args:         `--file testdata/unary_call.in --expect message=="kousaka" --expect-header foo api.Example.Unary`,
+			expectedCode: 2,
+		},
+		"call failure unary RPC without the expected status code": {
+			commonFlags:  "-r",
+			cmd:          "call",
+			args:         `--file testdata/unary_call.in --expect message=="oumae" api.Example.UnaryHeaderTrailerFailure`,
+			reflection:   true,
+			expectedCode: 1,
+		},
+		"call fails if an expectation is invalid": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "call",
+			args:         `--file testdata/unary_call.in --expect message==oumae api.Example.Unary`,
+			expectedCode: 1,
+		},
+
+		// record and replay
+
+		"call unary RPC with --record": {
+			commonFlags: "--proto testdata/test.proto --record testdata/record.jsonl",
+			cmd:         "call",
+			args:        "--file testdata/unary_call.in api.Example.Unary",
+			beforeTest: func(t *testing.T) func(*testing.T) {
+				return func(t *testing.T) {
+					defer os.Remove("testdata/record.jsonl")
+					b, err := os.ReadFile("testdata/record.jsonl")
+					if err != nil {
+						t.Fatalf("failed to read the capture file: %s", err)
+					}
+					var rec usecase.Record
+					if err := json.Unmarshal(b, &rec); err != nil {
+						t.Fatalf("failed to unmarshal the record: %s", err)
+					}
+					if rec.Method != "api.Example.Unary" || rec.Status.Code != "OK" || len(rec.Requests) != 1 || len(rec.Responses) != 1 {
+						t.Errorf("unexpected record: %s", b)
+					}
+					if res := string(rec.Responses[0].Message); res != `{"message":"oumae"}` {
+						t.Errorf("unexpected response: %s", res)
+					}
+				}
+			},
+			expectedOut: `{ "message": "oumae" }`,
+		},
+		"print replay command usage": {
+			commonFlags:      "",
+			cmd:              "replay",
+			args:             "-h",
+			assertWithGolden: true,
+		},
+		"replay recorded calls": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "replay",
+			args:        "testdata/session.jsonl",
+			expectedOut: `OK api.Example.Unary OK api.Example.ClientStreaming 2 calls replayed, 0 differ from the records`,
+		},
+		"replay fails if responses differ from the records": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "replay",
+			args:         "testdata/session_diff.jsonl",
+			expectedCode: 1,
+			assertTest: func(t *testing.T, output string) {
+				expected := `DIFF api.Example.Unary response #1: recorded {"message":"kousaka"}, but got {"message":"oumae"}`
+				if !strings.Contains(output, expected) {
+					t.Errorf("output must contain '%s', but got '%s'", expected, output)
+				}
+			},
+		},
+
+		// bench command
+
+		"print bench command usage": {
+			commonFlags:      "",
+			cmd:              "bench",
+			args:             "-h",
+			assertWithGolden: true,
+		},
+		"bench unary RPC": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "bench",
+			args:        "--file testdata/unary_call.in -c 2 -n 20 api.Example.Unary",
+			assertTest: func(t *testing.T, output string) {
+				for _, s := range []string{"Count: 20", "p99:", "Latency histogram:", "[OK] 20 responses"} {
+					if !strings.Contains(output, s) {
+						t.Errorf("output must contain '%s', but got:\n%s", s, output)
+					}
+				}
+			},
+		},
+		"bench server streaming RPC with JSON format": {
+			commonFlags: "--proto testdata/test.proto",
+			cmd:         "bench",
+			args:        "--file testdata/server_streaming.in -n 5 -o json api.Example.ServerStreaming",
+			assertTest: func(t *testing.T, output string) {
+				var report struct {
+					Count       int            `json:"count"`
+					StatusCodes map[string]int `json:"statusCodes"`
+				}
+				if err := json.Unmarshal([]byte(output), &report); err != nil {
+					t.Fatalf("failed to unmarshal the report: %s", err)
+				}
+				if report.Count != 5 || report.StatusCodes["OK"] != 5 {
+					t.Errorf("unexpected report: %s", output)
+				}
+			},
+		},
+		"bench fails if the concurrency is invalid": {
+			commonFlags:  "--proto testdata/test.proto",
+			cmd:          "bench",
+			args:         "--file testdata/unary_call.in -c 0 api.Example.Unary",
+			expectedCode: 1,
+		},
+
+		// health command
+
+		"print health command usage": {
+			commonFlags:      "",
+			cmd:              "health",
+			args:             "-h",
+			assertWithGolden: true,
+		},
+		"health fails if the server doesn't implement the health checking protocol": {
+			commonFlags:  "",
+			cmd:          "health",
+			args:         "",
+			expectedCode: 1,
+		},
+		"health checks the overall health of the server": {
+			commonFlags: "",
+			cmd:         "health",
+			args:        "",
+			health:      true,
+			expectedOut: "SERVING",
+		},
+		"health checks the health of the service": {
+			commonFlags: "",
+			cmd:         "health",
+			args:        "api.Serving",
+			health:      true,
+			expectedOut: "SERVING",
+		},
+		"health fails if the service is not serving": {
+			commonFlags:  "",
+			cmd:          "health",
+			args:         "api.NotServing",
+			health:       true,
+			expectedCode: 1,
+			assertTest: func(t *testing.T, output string) {
+				if output != "NOT_SERVING" {
+					t.Errorf("expected NOT_SERVING, but got '%s'", output)
+				}
+			},
+		},
 	}
 	for name, c := range cases {
 		c := c
 		t.Run(name, func(t *testing.T) {
 			defer usecase.Clear()
 
-			stopServer, port := startServer(t, c.tls, c.reflection, c.web, c.registerEmptyPackageService)
+			var (
+				stopServer func()
+				port       string
+			)
+			if c.health {
+				stopServer, port = startHealthServer(t)
+			} else {
+				stopServer, port = startServer(t, c.tls, c.reflection, c.web, c.registerEmptyPackageService)
+			}
 			defer stopServer()
 
 			outBuf, eoutBuf := new(bytes.Buffer), new(bytes.Buffer)
@@ -729,13 +1151,16 @@ func TestE2E_CLI(t *testing.T) {
 					// Trim "deprecated" message.
 					eout = strings.ReplaceAll(eout, color.YellowString("evans: deprecated usage, please use sub-commands. see `evans -h` for more details.")+"\n", "")
 				}
-				if eout != "" {
+				if eout != "" && c.assertErrTest == nil {
 					t.Errorf("expected code is 0, but got an error message: '%s'", eoutBuf.String())
 				}
 			}
 			if c.assertTest != nil {
 				c.assertTest(t, actual)
 			}
+			if c.assertErrTest != nil {
+				c.assertErrTest(t, eoutBuf.String())
+			}
 			if c.assertWithGolden {
 				s := outBuf.String()
 				s = replacer.ReplaceAllString(s, "")
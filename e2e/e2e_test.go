@@ -3,6 +3,7 @@ package e2e_test
 import (
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -16,6 +17,9 @@ import (
 	"github.com/ktr0731/grpc-test/server"
 	"github.com/phayes/freeport"
 	"go.uber.org/goleak"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	_ "github.com/ktr0731/evans/e2e/statik"
 )
@@ -88,6 +92,31 @@ func startServer(t *testing.T, useTLS, useReflection, useWeb, registerEmptyPacka
 	}, strconv.Itoa(port)
 }
 
+// startHealthServer starts a gRPC server which implements only the gRPC health checking protocol.
+// The overall status and api.Serving are SERVING, and api.NotServing is NOT_SERVING.
+// The test server started by startServer cannot register additional services, so it is started separately.
+func startHealthServer(t *testing.T) (func(), string) {
+	t.Helper()
+
+	port, err := freeport.GetFreePort()
+	if err != nil {
+		t.Fatalf("failed to get a free port for gRPC health server: %s", err)
+	}
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		t.Fatalf("failed to listen on port %d: %s", port, err)
+	}
+
+	hs := health.NewServer()
+	hs.SetServingStatus("api.Serving", healthpb.HealthCheckResponse_SERVING)
+	hs.SetServingStatus("api.NotServing", healthpb.HealthCheckResponse_NOT_SERVING)
+	s := grpc.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	go s.Serve(l) //nolint:errcheck
+
+	return s.Stop, strconv.Itoa(port)
+}
+
 func flatten(s string) string {
 	s = strings.ReplaceAll(s, "\n", " ")
 	s = strings.TrimSpace(s)
@@ -95,9 +124,14 @@ func flatten(s string) string {
 	return re.ReplaceAllString(s, " ")
 }
 
+// peerAddrReplacer replaces peer addresses with a placeholder because the test server listens on a random port.
+var peerAddrReplacer = regexp.MustCompile(`127\.0\.0\.1:[0-9]+`)
+
 func compareWithGolden(t *testing.T, actual string) {
 	t.Helper()
 
+	actual = peerAddrReplacer.ReplaceAllString(actual, "127.0.0.1:<port>")
+
 	name := t.Name()
 	normalizeFilename := func(name string) string {
 		fname := goldenPathReplacer.Replace(strings.ToLower(name)) + ".golden"
@@ -42,6 +42,9 @@ func TestE2E_REPL(t *testing.T) {
 
 		// hasErr checks whether REPL wrote some errors to UI.ErrWriter.
 		hasErr bool
+
+		// env is the environment variables set during the test.
+		env map[string]string
 	}{
 		// Common.
 
@@ -102,6 +105,78 @@ func TestE2E_REPL(t *testing.T) {
 			// io.EOF means end of inputting.
 			input: []interface{}{"call BidiStreaming", "kaguya", "chika", "miko", io.EOF},
 		},
+		"call Unary with --repeat": {
+			commonFlags: "--proto testdata/test.proto",
+			input:       []interface{}{"call Unary", "kaguya", "call --repeat Unary"},
+		},
+		"call ClientStreaming with --repeat": {
+			commonFlags: "--proto testdata/test.proto",
+			// io.EOF means end of inputting.
+			input: []interface{}{"call ClientStreaming", "kaguya", "chika", "miko", io.EOF, "call --repeat ClientStreaming"},
+		},
+		"call BidiStreaming with --repeat and --repeat-timing": {
+			commonFlags: "--proto testdata/test.proto",
+			// io.EOF means end of inputting.
+			input: []interface{}{"call BidiStreaming", "kaguya", "chika", io.EOF, "call --repeat --repeat-timing BidiStreaming"},
+		},
+		"call --repeat fails if there is no previous request": {
+			commonFlags: "--proto testdata/test.proto",
+			input:       []interface{}{"call --repeat ClientStreaming"},
+			skipGolden:  true,
+			hasErr:      true,
+		},
+		"call --repeat fails after history clear": {
+			commonFlags: "--proto testdata/test.proto",
+			input:       []interface{}{"call Unary", "kaguya", "history clear", "call --repeat Unary"},
+			hasErr:      true,
+		},
+		"call Unary with --edit": {
+			commonFlags: "--proto testdata/test.proto",
+			env:         map[string]string{"EDITOR": `sed -i 's/""/"chika"/'`},
+			input:       []interface{}{"call --edit Unary"},
+		},
+		"call Unary with --edit prefilled with the previous request": {
+			commonFlags: "--proto testdata/test.proto",
+			env:         map[string]string{"EDITOR": "sed -i s/kaguya/miko/"},
+			input:       []interface{}{"call Unary", "kaguya", "call --edit Unary"},
+		},
+		"call ClientStreaming with --edit prefilled with the previous requests": {
+			commonFlags: "--proto testdata/test.proto",
+			env:         map[string]string{"EDITOR": "sed -i s/chika/miko/"},
+			// io.EOF means end of inputting.
+			input: []interface{}{"call ClientStreaming", "kaguya", "chika", io.EOF, "call --edit ClientStreaming"},
+		},
+		"call --edit fails with --repeat": {
+			commonFlags: "--proto testdata/test.proto",
+			input:       []interface{}{"call Unary", "kaguya", "call --edit --repeat Unary"},
+			skipGolden:  true,
+			hasErr:      true,
+		},
+		"history lists the last calls": {
+			commonFlags: "--proto testdata/test.proto",
+			input:       []interface{}{"call Unary", "kaguya", "call Unary", "chika", "history -n 1"},
+			skipGolden:  true,
+		},
+		"history show": {
+			commonFlags: "--proto testdata/test.proto",
+			// io.EOF means end of inputting.
+			input: []interface{}{"call ClientStreaming", "kaguya", "chika", io.EOF, "history show 1"},
+		},
+		"history resend": {
+			commonFlags: "--proto testdata/test.proto",
+			input:       []interface{}{"call Unary", "kaguya", "history resend 1", "history show 2"},
+		},
+		"history edit": {
+			commonFlags: "--proto testdata/test.proto",
+			env:         map[string]string{"EDITOR": "sed -i s/kaguya/miko/"},
+			input:       []interface{}{"call Unary", "kaguya", "history edit 1"},
+		},
+		"history show fails if the call is not found": {
+			commonFlags: "--proto testdata/test.proto",
+			input:       []interface{}{"history show 1"},
+			skipGolden:  true,
+			hasErr:      true,
+		},
 		"call UnaryMessage": {
 			commonFlags: "--proto testdata/test.proto",
 			input:       []interface{}{"call UnaryMessage", "kaguya", "shinomiya"},
@@ -377,6 +452,12 @@ func TestE2E_REPL(t *testing.T) {
 			stopServer, port := startServer(t, c.tls, c.reflection, c.web, c.registerEmptyPackageService)
 			defer stopServer()
 
+			// Previous requests are saved in the cache for each server. The cache is isolated because ports may be reused.
+			t.Setenv("XDG_CACHE_HOME", t.TempDir())
+			for k, v := range c.env {
+				t.Setenv(k, v)
+			}
+
 			stubPrompt := &stubPrompt{
 				t:      t,
 				Prompt: oldNewPrompt(),
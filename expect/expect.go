@@ -0,0 +1,391 @@
+// Package expect provides assertions against the result of a call. They are used for smoke tests in CLI mode.
+package expect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ktr0731/evans/usecase"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+)
+
+// Expectations are assertions against the result of a call.
+type Expectations struct {
+	// code is the expected status code. nil if it isn't specified.
+	code     *codes.Code
+	fields   []*predicate
+	headers  []*metadataPredicate
+	trailers []*metadataPredicate
+}
+
+// New parses the expectations. code is the status code name such as "NOT_FOUND" or the number. It is ignored if empty.
+// fields are predicates over the response messages formed such as `path.to.field == "x"`.
+// headers and trailers are formed such as "key=value" or "key".
+func New(code string, fields, headers, trailers []string) (*Expectations, error) {
+	var e Expectations
+	if code != "" {
+		c, err := parseCode(code)
+		if err != nil {
+			return nil, err
+		}
+		e.code = &c
+	}
+	for _, f := range fields {
+		p, err := parsePredicate(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid --expect '%s'", f)
+		}
+		e.fields = append(e.fields, p)
+	}
+	for _, h := range headers {
+		e.headers = append(e.headers, parseMetadataPredicate("--expect-header", h))
+	}
+	for _, t := range trailers {
+		e.trailers = append(e.trailers, parseMetadataPredicate("--expect-trailer", t))
+	}
+	return &e, nil
+}
+
+// Empty returns whether e has no expectations.
+func (e *Expectations) Empty() bool {
+	return e.code == nil && len(e.fields) == 0 && len(e.headers) == 0 && len(e.trailers) == 0
+}
+
+// HasCode returns whether the status code is expected. If not, callers should treat non-OK status as errors.
+func (e *Expectations) HasCode() bool {
+	return e.code != nil
+}
+
+// Error is returned from Assert if one or more expectations are not satisfied.
+type Error struct {
+	// Failures describe the unsatisfied expectations.
+	Failures []string
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if len(e.Failures) == 1 {
+		b.WriteString("1 expectation failed:")
+	} else {
+		fmt.Fprintf(&b, "%d expectations failed:", len(e.Failures))
+	}
+	for _, f := range e.Failures {
+		b.WriteString("\n  ")
+		b.WriteString(f)
+	}
+	return b.String()
+}
+
+// Assert asserts the record of a call. It returns *Error if one or more expectations are not satisfied.
+func (e *Expectations) Assert(rec *usecase.Record) error {
+	var failures []string
+	if e.code != nil {
+		if actual := rec.Status.Code; normalizeCodeName(actual) != normalizeCodeName(e.code.String()) {
+			msg := fmt.Sprintf("--expect-code: expected %s, but got %s", e.code, actual)
+			if rec.Status.Message != "" {
+				msg += fmt.Sprintf(" (%s)", rec.Status.Message)
+			}
+			failures = append(failures, msg)
+		}
+	}
+	for _, p := range e.fields {
+		if len(rec.Responses) == 0 {
+			failures = append(failures, fmt.Sprintf("--expect '%s': no response messages", p.expr))
+			continue
+		}
+		for i, res := range rec.Responses {
+			if msg := p.eval(res.Message); msg != "" {
+				prefix := fmt.Sprintf("--expect '%s'", p.expr)
+				if len(rec.Responses) > 1 {
+					prefix += fmt.Sprintf(" (message #%d)", i+1)
+				}
+				failures = append(failures, prefix+": "+msg)
+			}
+		}
+	}
+	for _, p := range e.headers {
+		if msg := p.eval(rec.ResponseHeader); msg != "" {
+			failures = append(failures, msg)
+		}
+	}
+	for _, p := range e.trailers {
+		if msg := p.eval(rec.Trailer); msg != "" {
+			failures = append(failures, msg)
+		}
+	}
+	if len(failures) != 0 {
+		return &Error{Failures: failures}
+	}
+	return nil
+}
+
+// parseCode parses a status code name such as "NOT_FOUND", "NotFound" or the number such as "5".
+func parseCode(s string) (codes.Code, error) {
+	if n, err := strconv.ParseUint(s, 10, 32); err == nil && n <= uint64(codes.Unauthenticated) {
+		return codes.Code(n), nil
+	}
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if normalizeCodeName(c.String()) == normalizeCodeName(s) {
+			return c, nil
+		}
+	}
+	return 0, errors.Errorf("unknown status code '%s'", s)
+}
+
+// normalizeCodeName normalizes the code name so that "NOT_FOUND" and "NotFound" are regarded as the same.
+func normalizeCodeName(s string) string {
+	return strings.ToUpper(strings.ReplaceAll(s, "_", ""))
+}
+
+// operators are the available operators. Longer operators must come first because "<" is a prefix of "<=".
+var operators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+// predicate is a predicate over a response message formed such as `path.to.field == "x"`.
+// If the operator is omitted, the predicate checks whether the field exists.
+type predicate struct {
+	expr string
+	path []string
+	op   string
+	// value is the right-hand side. It is decoded by json.Decoder.UseNumber.
+	value interface{}
+}
+
+func parsePredicate(expr string) (*predicate, error) {
+	p := &predicate{expr: expr}
+	// The first operator is used because field paths never contain operators.
+	lhs, i := expr, -1
+	for _, op := range operators {
+		if j := strings.Index(expr, op); j != -1 && (i == -1 || j < i) {
+			i, p.op = j, op
+		}
+	}
+	if i != -1 {
+		lhs = expr[:i]
+		v, err := decodeJSON([]byte(strings.TrimSpace(expr[i+len(p.op):])))
+		if err != nil {
+			return nil, errors.New(`the right-hand side must be a JSON value such as "x", 1 or true`)
+		}
+		p.value = v
+		if p.op != "==" && p.op != "!=" {
+			if _, ok := toNumber(v); !ok {
+				return nil, errors.Errorf("the right-hand side of '%s' must be a number", p.op)
+			}
+		}
+	}
+	path, err := parsePath(strings.TrimSpace(lhs))
+	if err != nil {
+		return nil, err
+	}
+	p.path = path
+	return p, nil
+}
+
+// parsePath parses a path such as "a.b[0].c" into "a", "b", "0" and "c".
+func parsePath(s string) ([]string, error) {
+	s = strings.TrimPrefix(s, ".")
+	if s == "" {
+		return nil, errors.New("field path is required")
+	}
+	s = strings.NewReplacer("[", ".", "]", "").Replace(s)
+	path := strings.Split(s, ".")
+	for _, p := range path {
+		if p == "" || strings.ContainsAny(p, " \t") {
+			return nil, errors.Errorf("malformed field path '%s'", s)
+		}
+	}
+	return path, nil
+}
+
+// eval evaluates p with msg. It returns the description of the failure, or an empty string if msg satisfies p.
+func (p *predicate) eval(msg json.RawMessage) string {
+	v, err := decodeJSON(msg)
+	if err != nil {
+		return fmt.Sprintf("failed to decode the response: %s", err)
+	}
+	actual, ok := lookup(v, p.path)
+	if p.op == "" {
+		if !ok {
+			return "the field doesn't exist"
+		}
+		return ""
+	}
+	if !ok {
+		actual = nil
+	}
+
+	switch p.op {
+	case "==":
+		if !equal(p.value, actual) {
+			return fmt.Sprintf("expected %s, but got %s", formatValue(p.value), formatValue(actual))
+		}
+	case "!=":
+		if equal(p.value, actual) {
+			return fmt.Sprintf("expected not %s, but got %s", formatValue(p.value), formatValue(actual))
+		}
+	default:
+		expected, _ := toNumber(p.value)
+		n, ok := toNumber(actual)
+		if !ok {
+			return fmt.Sprintf("expected a number %s %s, but got %s", p.op, formatValue(p.value), formatValue(actual))
+		}
+		var satisfied bool
+		switch p.op {
+		case "<":
+			satisfied = n < expected
+		case "<=":
+			satisfied = n <= expected
+		case ">":
+			satisfied = n > expected
+		case ">=":
+			satisfied = n >= expected
+		}
+		if !satisfied {
+			return fmt.Sprintf("expected %s %s, but got %s", p.op, formatValue(p.value), formatValue(actual))
+		}
+	}
+	return ""
+}
+
+// lookup looks up the value at path. Field names may be the original names such as "field_name" instead of
+// the JSON names such as "fieldName".
+func lookup(v interface{}, path []string) (interface{}, bool) {
+	for _, p := range path {
+		switch vv := v.(type) {
+		case map[string]interface{}:
+			next, ok := vv[p]
+			if !ok {
+				next, ok = vv[jsonName(p)]
+			}
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(p)
+			if err != nil || i < 0 || i >= len(vv) {
+				return nil, false
+			}
+			v = vv[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// jsonName converts a field name such as "field_name" to the JSON name "fieldName".
+func jsonName(s string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range s {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper && 'a' <= r && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		upper = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// equal compares a and b. Numbers are compared as numbers because 64-bit integers are formatted as strings in JSON.
+func equal(a, b interface{}) bool {
+	if an, ok := a.(json.Number); ok {
+		bn, ok := toNumber(b)
+		if !ok {
+			return false
+		}
+		n, _ := toNumber(an)
+		return n == bn
+	}
+	ab, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	bb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ab, bb)
+}
+
+// toNumber converts v to a number. Strings which represent numbers are also converted.
+func toNumber(v interface{}) (float64, bool) {
+	var s string
+	switch vv := v.(type) {
+	case json.Number:
+		s = vv.String()
+	case string:
+		s = vv
+	default:
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+func decodeJSON(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("trailing data")
+	}
+	return v, nil
+}
+
+func formatValue(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// metadataPredicate checks whether metadata has the key and the value.
+type metadataPredicate struct {
+	flag  string
+	expr  string
+	key   string
+	value *string
+}
+
+// parseMetadataPredicate parses expr formed such as "key=value" or "key". Keys are case-insensitive.
+func parseMetadataPredicate(flag, expr string) *metadataPredicate {
+	p := &metadataPredicate{flag: flag, expr: expr}
+	k, v, ok := strings.Cut(expr, "=")
+	p.key = strings.ToLower(strings.TrimSpace(k))
+	if ok {
+		p.value = &v
+	}
+	return p
+}
+
+func (p *metadataPredicate) eval(md map[string][]string) string {
+	values, ok := md[p.key]
+	if !ok {
+		return fmt.Sprintf("%s '%s': the key '%s' doesn't exist", p.flag, p.expr, p.key)
+	}
+	if p.value == nil {
+		return ""
+	}
+	for _, v := range values {
+		if v == *p.value {
+			return ""
+		}
+	}
+	return fmt.Sprintf("%s '%s': expected %q, but got %s", p.flag, p.expr, *p.value, formatValue(values))
+}
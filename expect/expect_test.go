@@ -0,0 +1,137 @@
+package expect
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ktr0731/evans/usecase"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestNew(t *testing.T) {
+	cases := map[string]struct {
+		code   string
+		fields []string
+
+		hasErr bool
+	}{
+		"code name":                     {code: "NOT_FOUND"},
+		"camel case code name":          {code: "NotFound"},
+		"code number":                   {code: "5"},
+		"unknown code":                  {code: "NOT_FOUNDD", hasErr: true},
+		"out of range code":             {code: "17", hasErr: true},
+		"existence":                     {fields: []string{"a.b"}},
+		"comparison":                    {fields: []string{`a.b[0] == "x"`, "a >= 1", "a != null"}},
+		"operator in the value":         {fields: []string{`a == "b <= c"`}},
+		"no path":                       {fields: []string{`== "x"`}, hasErr: true},
+		"not JSON value":                {fields: []string{`a == x`}, hasErr: true},
+		"ordering with non-number":      {fields: []string{`a < "x"`}, hasErr: true},
+		"ordering with a number string": {fields: []string{`a < "10"`}},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			_, err := New(c.code, c.fields, nil, nil)
+			if c.hasErr {
+				if err == nil {
+					t.Error("New must return an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("New must not return an error, but got '%s'", err)
+			}
+		})
+	}
+}
+
+func TestExpectations_Assert(t *testing.T) {
+	newRecord := func(code string, msgs ...string) *usecase.Record {
+		rec := &usecase.Record{
+			ResponseHeader: metadata.Pairs("content-type", "application/grpc", "x-foo", "bar"),
+			Trailer:        metadata.Pairs("x-request-id", "1"),
+			Status:         &usecase.RecordedStatus{Code: code},
+		}
+		for _, m := range msgs {
+			rec.Responses = append(rec.Responses, &usecase.RecordedMessage{Message: json.RawMessage(m)})
+		}
+		return rec
+	}
+
+	cases := map[string]struct {
+		code                      string
+		fields, headers, trailers []string
+		rec                       *usecase.Record
+
+		expected []string
+	}{
+		"satisfied": {
+			code:     "OK",
+			fields:   []string{`name == "foo"`, "items[1].count >= 2", "items.0", "big_num == 10", "name != null"},
+			headers:  []string{"X-Foo=bar", "content-type"},
+			trailers: []string{"x-request-id"},
+			rec:      newRecord("OK", `{"name": "foo", "items": [{"count": 1}, {"count": 2}], "bigNum": "10"}`),
+		},
+		"unexpected code": {
+			code:     "NOT_FOUND",
+			rec:      &usecase.Record{Status: &usecase.RecordedStatus{Code: "Internal", Message: "oops"}},
+			expected: []string{"--expect-code: expected NotFound, but got Internal (oops)"},
+		},
+		"unexpected value": {
+			fields:   []string{`name == "bar"`, "count > 3", "missing"},
+			rec:      newRecord("OK", `{"name": "foo", "count": 3}`),
+			expected: []string{`--expect 'name == "bar"': expected "bar", but got "foo"`, `--expect 'count > 3': expected > 3, but got 3`, `--expect 'missing': the field doesn't exist`},
+		},
+		"each message of a stream": {
+			fields:   []string{"n < 2"},
+			rec:      newRecord("OK", `{"n": 1}`, `{"n": 2}`),
+			expected: []string{`--expect 'n < 2' (message #2): expected < 2, but got 2`},
+		},
+		"no messages": {
+			code:     "UNAVAILABLE",
+			fields:   []string{"n"},
+			rec:      newRecord("Unavailable"),
+			expected: []string{`--expect 'n': no response messages`},
+		},
+		"unexpected metadata": {
+			headers:  []string{"x-foo=baz", "x-bar"},
+			trailers: []string{"x-request-id=2"},
+			rec:      newRecord("OK"),
+			expected: []string{
+				`--expect-header 'x-foo=baz': expected "baz", but got ["bar"]`,
+				`--expect-header 'x-bar': the key 'x-bar' doesn't exist`,
+				`--expect-trailer 'x-request-id=2': expected "2", but got ["1"]`,
+			},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			e, err := New(c.code, c.fields, c.headers, c.trailers)
+			if err != nil {
+				t.Fatalf("New must not return an error, but got '%s'", err)
+			}
+			err = e.Assert(c.rec)
+			if len(c.expected) == 0 {
+				if err != nil {
+					t.Errorf("Assert must not return an error, but got '%s'", err)
+				}
+				return
+			}
+			var eerr *Error
+			if !errors.As(err, &eerr) {
+				t.Fatalf("Assert must return *Error, but got '%v'", err)
+			}
+			if diff := cmp.Diff(c.expected, eerr.Failures); diff != "" {
+				t.Errorf("-want, +got\n%s", diff)
+			}
+			if header := strings.SplitN(eerr.Error(), "\n", 2)[0]; !strings.HasPrefix(header, fmt.Sprintf("%d expectation", len(c.expected))) {
+				t.Errorf("unexpected error message header: %s", header)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package fill
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// exampleTimestamp is the seconds of the representative google.protobuf.Timestamp, 2006-01-02T15:04:05Z.
+const exampleTimestamp = 1136214245
+
+// ExampleMessage returns a message of md which is filled with representative values.
+// Nested messages are filled recursively until depth levels. Deeper message fields are not set.
+// Each repeated and map field has an element, and each oneof has the first field.
+// Fields which are not set by ExampleMessage such as scalar fields have the zero values.
+func ExampleMessage(md protoreflect.MessageDescriptor, depth int) *dynamicpb.Message {
+	msg := dynamicpb.NewMessage(md)
+	fillExample(msg, depth)
+	return msg
+}
+
+func fillExample(msg protoreflect.Message, depth int) {
+	md := msg.Descriptor()
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		msg.Set(md.Fields().ByName("seconds"), protoreflect.ValueOfInt64(exampleTimestamp))
+		return
+	case "google.protobuf.Duration":
+		msg.Set(md.Fields().ByName("seconds"), protoreflect.ValueOfInt64(1))
+		return
+	}
+
+	for i := 0; i < md.Fields().Len(); i++ {
+		fd := md.Fields().Get(i)
+		if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() && od.Fields().Get(0) != fd {
+			continue
+		}
+		switch {
+		case fd.IsList():
+			if v, ok := exampleValue(msg.NewField(fd).List().NewElement(), fd, depth); ok {
+				msg.Mutable(fd).List().Append(v)
+			}
+		case fd.IsMap():
+			m := msg.NewField(fd).Map()
+			if v, ok := exampleValue(m.NewValue(), fd.MapValue(), depth); ok {
+				msg.Mutable(fd).Map().Set(fd.MapKey().Default().MapKey(), v)
+			}
+		case fd.Message() != nil:
+			if v, ok := exampleValue(msg.NewField(fd), fd, depth); ok {
+				msg.Set(fd, v)
+			}
+		case fd.HasPresence():
+			// Fields of oneofs and optional fields are emitted only if they are set.
+			msg.Set(fd, fd.Default())
+		}
+	}
+}
+
+// exampleValue fills v, a new element of fd. Scalar elements are the zero values. It returns false if v should not
+// be set.
+func exampleValue(v protoreflect.Value, fd protoreflect.FieldDescriptor, depth int) (protoreflect.Value, bool) {
+	if fd.Message() == nil {
+		return v, true
+	}
+	switch fd.Message().FullName() {
+	case "google.protobuf.Any", "google.protobuf.Value":
+		// They cannot be formatted without a type or a kind.
+		return v, false
+	}
+	if !isWellKnownType(fd.Message()) {
+		if depth <= 0 {
+			return v, false
+		}
+		depth--
+	}
+	fillExample(v.Message(), depth)
+	return v, true
+}
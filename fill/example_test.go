@@ -0,0 +1,60 @@
+package fill_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ktr0731/evans/fill"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestExampleMessage(t *testing.T) {
+	md := compileSkeletonProto(t)
+
+	b, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(fill.ExampleMessage(md, 1))
+	if err != nil {
+		t.Fatalf("the example must be marshaled, but got an error: '%s'", err)
+	}
+	var actual bytes.Buffer
+	if err := json.Indent(&actual, b, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{
+  "name": "",
+  "lang": "LANG_UNSPECIFIED",
+  "number": "0",
+  "parent": {
+    "name": "",
+    "lang": "LANG_UNSPECIFIED",
+    "number": "0",
+    "parent": null,
+    "createdAt": "2006-01-02T15:04:05Z",
+    "nickname": "",
+    "tags": [
+      ""
+    ],
+    "counts": {
+      "": 0
+    },
+    "nested": null
+  },
+  "createdAt": "2006-01-02T15:04:05Z",
+  "nickname": "",
+  "tags": [
+    ""
+  ],
+  "counts": {
+    "": 0
+  },
+  "nested": {
+    "data": "",
+    "ok": false
+  }
+}`
+	if diff := cmp.Diff(expected, actual.String()); diff != "" {
+		t.Errorf("-want, +got\n%s", diff)
+	}
+}
@@ -35,6 +35,8 @@ type InteractiveFillerOpts struct {
 	// AddRepeatedManually is true, Fill asks whether to add a repeated field value
 	// if it encountered to a repeated field.
 	AddRepeatedManually bool
+	// Template is not nil, Fill renders each input by the template.
+	Template *Template
 }
 
 // Filler tries to correspond input text to a struct interactively.
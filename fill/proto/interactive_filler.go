@@ -371,6 +371,12 @@ func (r *resolver) input(prefix string, f protoreflect.FieldDescriptor, converte
 	if err != nil {
 		return protoreflect.Value{}, err
 	}
+	if r.opts.Template != nil {
+		in, err = r.opts.Template.Render(in)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+	}
 	if in == "" {
 		if f.IsList() {
 			return defaultValueFromKind(f.Kind()), nil
@@ -117,6 +117,44 @@ func TestInteractiveFiller(t *testing.T) {
 	}
 }
 
+func TestInteractiveFiller_template(t *testing.T) {
+	c := &protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: []string{"testdata"},
+		}),
+	}
+	compiled, err := c.Compile(context.TODO(), "test.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := compiled[0].Messages().ByName(protoreflect.Name("Message"))
+	msg := dynamicpb.NewMessage(m)
+	input := make([]string, 15) // c to q.
+	input[0] = "{{ randInt 2 2 }}"
+	input[13] = "{{ .name }}"
+	p := &stubPrompt{
+		t:     t,
+		input: input,
+		selection: []int{
+			1, // a - no
+			0, // b - enum1
+		},
+	}
+	f := NewInteractiveFiller(p, "")
+	opts := fill.InteractiveFillerOpts{Template: fill.NewTemplate(map[string]string{"name": "kumiko"})}
+	if err := f.Fill(msg, opts); err != nil {
+		t.Fatalf("should not return an error, but got '%s'", err)
+	}
+
+	if c := msg.Get(m.Fields().ByName("c")).Float(); c != 2 {
+		t.Errorf("c must be 2, but got %f", c)
+	}
+	if p := msg.Get(m.Fields().ByName("p")).String(); p != "kumiko" {
+		t.Errorf("p must be kumiko, but got %s", p)
+	}
+}
+
 func Test_defaultValueFromKind(t *testing.T) {
 	cases := map[string]struct {
 		kind protoreflect.Kind
@@ -0,0 +1,245 @@
+package fill
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// JSONSkeleton returns a JSON object which has all fields of md. It is used as a template to write a request in an
+// editor. Each field has the zero value, or the value of prefill if prefill is not nil and the field is set.
+// Fields which track presence such as message fields are null if prefill is not nil and they are not set.
+// The object has comments to describe enum values, oneof fields and recursive fields. Comments must be removed by
+// StripJSONComments before unmarshaling.
+func JSONSkeleton(md protoreflect.MessageDescriptor, prefill protoreflect.Message) (string, error) {
+	w := &skeletonWriter{visiting: map[protoreflect.FullName]bool{}}
+	s, err := w.message(md, prefill, "")
+	if err != nil {
+		return "", err
+	}
+	return s + "\n", nil
+}
+
+type skeletonWriter struct {
+	// visiting is the set of messages being written. It is used to stop writing recursive messages.
+	visiting map[protoreflect.FullName]bool
+}
+
+type skeletonField struct {
+	name, value string
+	// comment is written in the previous line of the field.
+	comment string
+	// trailingComment is written after the value.
+	trailingComment string
+}
+
+func (w *skeletonWriter) message(md protoreflect.MessageDescriptor, msg protoreflect.Message, indent string) (string, error) {
+	w.visiting[md.FullName()] = true
+	defer delete(w.visiting, md.FullName())
+
+	// Values which are set are formatted by protojson.
+	var set map[string]json.RawMessage
+	if msg != nil {
+		b, err := protojson.Marshal(msg.Interface())
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to marshal %s", md.FullName())
+		}
+		if err := json.Unmarshal(b, &set); err != nil {
+			return "", errors.Wrapf(err, "failed to unmarshal %s", md.FullName())
+		}
+	}
+
+	fieldIndent := indent + "  "
+	var fields []*skeletonField
+	for i := 0; i < md.Fields().Len(); i++ {
+		fd := md.Fields().Get(i)
+		var comment string
+		if od := fd.ContainingOneof(); od != nil && !od.IsSynthetic() {
+			// Only one field of a oneof can be set. The field which is set in msg or the first one is written.
+			chosen := od.Fields().Get(0)
+			if msg != nil {
+				if f := msg.WhichOneof(od); f != nil {
+					chosen = f
+				}
+			}
+			if fd != chosen {
+				continue
+			}
+			names := make([]string, 0, od.Fields().Len())
+			for j := 0; j < od.Fields().Len(); j++ {
+				names = append(names, strconv.Quote(od.Fields().Get(j).JSONName()))
+			}
+			comment = fmt.Sprintf("oneof %s: only one of %s can be set", od.Name(), strings.Join(names, ", "))
+		}
+
+		f := &skeletonField{name: fd.JSONName(), comment: comment}
+		if ed := fd.Enum(); ed != nil && ed.FullName() != "google.protobuf.NullValue" {
+			f.trailingComment = enumValues(ed)
+		}
+
+		switch raw, ok := set[fd.JSONName()]; {
+		case msg != nil && !ok && fd.HasPresence():
+			// Writing the zero value sets the field. null keeps it unset.
+			f.value = "null"
+			if f.trailingComment == "" {
+				f.trailingComment = typeName(fd)
+			}
+		case fd.Message() != nil && !fd.IsList() && !fd.IsMap() && !isWellKnownType(fd.Message()):
+			var sub protoreflect.Message
+			if ok {
+				sub = msg.Get(fd).Message()
+			}
+			if w.visiting[fd.Message().FullName()] && sub == nil {
+				f.value = "null"
+				f.trailingComment = fmt.Sprintf("%s is recursive", fd.Message().FullName())
+				break
+			}
+			v, err := w.message(fd.Message(), sub, fieldIndent)
+			if err != nil {
+				return "", err
+			}
+			f.value = v
+		case ok:
+			var buf bytes.Buffer
+			if err := json.Indent(&buf, raw, fieldIndent, "  "); err != nil {
+				return "", errors.Wrapf(err, "failed to format %s", fd.FullName())
+			}
+			f.value = buf.String()
+		default:
+			f.value = zeroJSONValue(fd)
+		}
+		fields = append(fields, f)
+	}
+
+	if len(fields) == 0 {
+		return "{}", nil
+	}
+	var b strings.Builder
+	b.WriteString("{\n")
+	for i, f := range fields {
+		if f.comment != "" {
+			fmt.Fprintf(&b, "%s// %s\n", fieldIndent, f.comment)
+		}
+		fmt.Fprintf(&b, "%s%q: %s", fieldIndent, f.name, f.value)
+		if i != len(fields)-1 {
+			b.WriteString(",")
+		}
+		if f.trailingComment != "" {
+			fmt.Fprintf(&b, " // %s", f.trailingComment)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(indent + "}")
+	return b.String(), nil
+}
+
+// zeroJSONValue returns the zero value of fd in JSON.
+func zeroJSONValue(fd protoreflect.FieldDescriptor) string {
+	switch {
+	case fd.IsList():
+		return "[]"
+	case fd.IsMap():
+		return "{}"
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return wellKnownTypeZeroValues[fd.Message().FullName()]
+	case protoreflect.EnumKind:
+		if fd.Enum().FullName() == "google.protobuf.NullValue" {
+			return "null"
+		}
+		return strconv.Quote(string(fd.Enum().Values().Get(0).Name()))
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return `""`
+	case protoreflect.BoolKind:
+		return "false"
+	default:
+		return "0"
+	}
+}
+
+// wellKnownTypeZeroValues are the zero values of well-known types. They have special JSON representations.
+var wellKnownTypeZeroValues = map[protoreflect.FullName]string{
+	"google.protobuf.Any":         "null",
+	"google.protobuf.Timestamp":   `"1970-01-01T00:00:00Z"`,
+	"google.protobuf.Duration":    `"0s"`,
+	"google.protobuf.Struct":      "{}",
+	"google.protobuf.Value":       "null",
+	"google.protobuf.ListValue":   "[]",
+	"google.protobuf.FieldMask":   `""`,
+	"google.protobuf.Empty":       "{}",
+	"google.protobuf.DoubleValue": "0",
+	"google.protobuf.FloatValue":  "0",
+	"google.protobuf.Int64Value":  "0",
+	"google.protobuf.UInt64Value": "0",
+	"google.protobuf.Int32Value":  "0",
+	"google.protobuf.UInt32Value": "0",
+	"google.protobuf.BoolValue":   "false",
+	"google.protobuf.StringValue": `""`,
+	"google.protobuf.BytesValue":  `""`,
+}
+
+func isWellKnownType(md protoreflect.MessageDescriptor) bool {
+	_, ok := wellKnownTypeZeroValues[md.FullName()]
+	return ok
+}
+
+func typeName(fd protoreflect.FieldDescriptor) string {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return string(fd.Message().FullName())
+	case protoreflect.EnumKind:
+		return string(fd.Enum().FullName())
+	default:
+		return fd.Kind().String()
+	}
+}
+
+func enumValues(ed protoreflect.EnumDescriptor) string {
+	names := make([]string, 0, ed.Values().Len())
+	for i := 0; i < ed.Values().Len(); i++ {
+		names = append(names, string(ed.Values().Get(i).Name()))
+	}
+	return strings.Join(names, " | ")
+}
+
+// StripJSONComments removes line comments starting with "//" from s. "//" in JSON strings is kept.
+func StripJSONComments(s string) string {
+	var (
+		b        strings.Builder
+		inString bool
+		escaped  bool
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '/' && i+1 < len(s) && s[i+1] == '/':
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+			if i == len(s) {
+				return b.String()
+			}
+			c = s[i]
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package fill_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ktr0731/evans/fill"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const skeletonProto = `
+syntax = "proto3";
+
+package skeleton;
+
+import "google/protobuf/timestamp.proto";
+import "google/protobuf/wrappers.proto";
+
+enum Lang {
+  LANG_UNSPECIFIED = 0;
+  ENGLISH = 1;
+  JAPANESE = 2;
+}
+
+message Request {
+  string name = 1;
+  Lang lang = 2;
+  oneof id {
+    int64 number = 3;
+    string uuid = 4;
+  }
+  Request parent = 5;
+  google.protobuf.Timestamp created_at = 6;
+  google.protobuf.StringValue nickname = 7;
+  repeated string tags = 8;
+  map<string, int32> counts = 9;
+  Nested nested = 10;
+}
+
+message Nested {
+  bytes data = 1;
+  bool ok = 2;
+}
+`
+
+func compileSkeletonProto(t *testing.T) protoreflect.MessageDescriptor {
+	t.Helper()
+	c := &protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(map[string]string{"skeleton.proto": skeletonProto}),
+		}),
+	}
+	compiled, err := c.Compile(context.TODO(), "skeleton.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return compiled[0].Messages().ByName("Request")
+}
+
+func TestJSONSkeleton(t *testing.T) {
+	md := compileSkeletonProto(t)
+
+	t.Run("zero values", func(t *testing.T) {
+		s, err := fill.JSONSkeleton(md, nil)
+		if err != nil {
+			t.Fatalf("JSONSkeleton must not return an error, but got '%s'", err)
+		}
+		expected := `{
+  "name": "",
+  "lang": "LANG_UNSPECIFIED", // LANG_UNSPECIFIED | ENGLISH | JAPANESE
+  // oneof id: only one of "number", "uuid" can be set
+  "number": 0,
+  "parent": null, // skeleton.Request is recursive
+  "createdAt": "1970-01-01T00:00:00Z",
+  "nickname": "",
+  "tags": [],
+  "counts": {},
+  "nested": {
+    "data": "",
+    "ok": false
+  }
+}
+`
+		if diff := cmp.Diff(expected, s); diff != "" {
+			t.Errorf("-want, +got\n%s", diff)
+		}
+
+		f := fill.NewSilentFiller(strings.NewReader(fill.StripJSONComments(s)))
+		if err := f.Fill(dynamicpb.NewMessage(md)); err != nil {
+			t.Errorf("the skeleton must be a valid request, but got an error: '%s'", err)
+		}
+	})
+
+	t.Run("prefilled", func(t *testing.T) {
+		prefill := dynamicpb.NewMessage(md)
+		in := `{"name": "// not a comment", "lang": "JAPANESE", "uuid": "foo", "parent": {"name": "bar"}, "tags": ["a", "b"], "nested": {"ok": true}}`
+		if err := protojson.Unmarshal([]byte(in), prefill); err != nil {
+			t.Fatal(err)
+		}
+		s, err := fill.JSONSkeleton(md, prefill)
+		if err != nil {
+			t.Fatalf("JSONSkeleton must not return an error, but got '%s'", err)
+		}
+		if !strings.Contains(s, `"uuid": "foo",`) || !strings.Contains(s, `"createdAt": null, // google.protobuf.Timestamp`) {
+			t.Errorf("the field which is set in the oneof and null for unset message fields must be written, but got:\n%s", s)
+		}
+
+		actual := dynamicpb.NewMessage(md)
+		f := fill.NewSilentFiller(strings.NewReader(fill.StripJSONComments(s)))
+		if err := f.Fill(actual); err != nil {
+			t.Fatalf("the skeleton must be a valid request, but got an error: '%s'", err)
+		}
+		if !proto.Equal(prefill, actual) {
+			t.Errorf("the request must be the same as prefill, but got:\n%s", s)
+		}
+	})
+}
+
+func TestStripJSONComments(t *testing.T) {
+	in := `{
+  "name": "// foo \" // bar", // comment
+  // comment
+  "url": "http://example.com"
+} // comment`
+	expected := `{
+  "name": "// foo \" // bar", 
+  
+  "url": "http://example.com"
+} `
+	if diff := cmp.Diff(expected, fill.StripJSONComments(in)); diff != "" {
+		t.Errorf("-want, +got\n%s", diff)
+	}
+}
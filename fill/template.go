@@ -0,0 +1,202 @@
+package fill
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Template renders request input by text/template. Variables are referred as {{ .key }}.
+// The following functions are available in addition to the text/template builtins:
+//
+//   - env "NAME": the environment variable.
+//   - uuid: a random UUID (version 4).
+//   - now: the current time.
+//   - rfc3339 t, unix t: t formatted as RFC 3339 or Unix time.
+//   - randInt min max: a random integer in [min, max].
+//   - file "path": the contents of the file.
+//   - base64 s: s encoded as base64.
+type Template struct {
+	vars  map[string]string
+	funcs template.FuncMap
+}
+
+// NewTemplate returns a new Template that has vars as the variables.
+func NewTemplate(vars map[string]string) *Template {
+	if vars == nil {
+		vars = map[string]string{}
+	}
+	return &Template{
+		vars: vars,
+		funcs: template.FuncMap{
+			"env":  os.Getenv,
+			"uuid": func() string { return uuid.NewString() },
+			"now":  time.Now,
+			"rfc3339": func(t time.Time) string {
+				return t.Format(time.RFC3339)
+			},
+			"unix": func(t time.Time) int64 {
+				return t.Unix()
+			},
+			"randInt": func(min, max int) (int, error) {
+				if min > max {
+					return 0, errors.Errorf("randInt: min (%d) must not be greater than max (%d)", min, max)
+				}
+				return min + rand.Intn(max-min+1), nil //nolint:gosec
+			},
+			"file": func(name string) (string, error) {
+				b, err := os.ReadFile(name)
+				if err != nil {
+					return "", err
+				}
+				return string(b), nil
+			},
+			"base64": func(s string) string {
+				return base64.StdEncoding.EncodeToString([]byte(s))
+			},
+		},
+	}
+}
+
+// ParseVars parses variables formed such as "key=value".
+func ParseVars(vars []string) (map[string]string, error) {
+	m := make(map[string]string, len(vars))
+	for _, kv := range vars {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, errors.Errorf("variable '%s' must be formed as key=value", kv)
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+// Render renders text. Functions are evaluated each time, so uuid returns a different value for each call.
+func (t *Template) Render(text string) (string, error) {
+	tmpl, err := template.New("input").Funcs(t.funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse the template")
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, t.vars); err != nil {
+		return "", errors.Wrap(err, "failed to render the template")
+	}
+	return buf.String(), nil
+}
+
+// TemplateFiller is a Filler implementation that renders each JSON message by Template before filling values.
+// Each message is rendered just before it is filled, so streaming inputs get fresh values each time.
+type TemplateFiller struct {
+	tmpl *Template
+	in   *bufio.Reader
+}
+
+// NewTemplateFiller receives input as io.Reader and returns an instance of TemplateFiller.
+// The input is a sequence of JSON objects that may contain template actions such as {{ uuid }}.
+// A template action which isn't in any objects is also regarded as a message.
+func NewTemplateFiller(in io.Reader, tmpl *Template) *TemplateFiller {
+	return &TemplateFiller{tmpl: tmpl, in: bufio.NewReader(in)}
+}
+
+// Fill fills values of each field from the next rendered JSON message.
+func (f *TemplateFiller) Fill(v *dynamicpb.Message) error {
+	text, err := f.next()
+	if err != nil {
+		return err
+	}
+	rendered, err := f.tmpl.Render(text)
+	if err != nil {
+		return err
+	}
+	return protojson.Unmarshal([]byte(rendered), v)
+}
+
+// next reads the next JSON object without rendering. Template actions are skipped as they are because they may
+// contain quotes and braces.
+func (f *TemplateFiller) next() (string, error) {
+	var (
+		b        strings.Builder
+		depth    int
+		inString bool
+	)
+	for {
+		r, _, err := f.in.ReadRune()
+		if errors.Is(err, io.EOF) {
+			if depth == 0 && strings.TrimSpace(b.String()) == "" {
+				return "", io.EOF
+			}
+			return "", io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if depth == 0 && !inString {
+			if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+				continue
+			}
+			if r != '{' {
+				return "", errors.Errorf("a message must be a JSON object, but got '%c'", r)
+			}
+		}
+		b.WriteRune(r)
+
+		if r == '{' {
+			if next, err := f.in.Peek(1); err == nil && next[0] == '{' {
+				if err := f.skipAction(&b); err != nil {
+					return "", err
+				}
+				// The action which isn't in any objects such as {{ file "req.json" }} is regarded as a message.
+				if depth == 0 {
+					return b.String(), nil
+				}
+				continue
+			}
+		}
+		switch {
+		case inString && r == '\\':
+			escaped, _, err := f.in.ReadRune()
+			if err != nil {
+				return "", io.ErrUnexpectedEOF
+			}
+			b.WriteRune(escaped)
+		case r == '"':
+			inString = !inString
+		case inString:
+		case r == '{' || r == '[':
+			depth++
+		case r == '}' || r == ']':
+			depth--
+			if depth == 0 {
+				return b.String(), nil
+			}
+		}
+	}
+}
+
+// skipAction writes the template action to b. The leading "{" is already written.
+func (f *TemplateFiller) skipAction(b *strings.Builder) error {
+	var prev rune
+	for {
+		r, _, err := f.in.ReadRune()
+		if err != nil {
+			return errors.New("unclosed template action")
+		}
+		b.WriteRune(r)
+		if prev == '}' && r == '}' {
+			return nil
+		}
+		prev = r
+	}
+}
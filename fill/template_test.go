@@ -0,0 +1,152 @@
+package fill_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ktr0731/evans/fill"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func TestTemplate_Render(t *testing.T) {
+	t.Setenv("EVANS_TEST_TENANT", "kitauji")
+	file := filepath.Join(t.TempDir(), "x.bin")
+	if err := os.WriteFile(file, []byte("oumae"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		text string
+
+		expected string
+		hasErr   bool
+	}{
+		"variable":           {text: `{{ .name }}`, expected: "kumiko"},
+		"env":                {text: `{{ env "EVANS_TEST_TENANT" }}`, expected: "kitauji"},
+		"file and base64":    {text: `{{ file "` + file + `" | base64 }}`, expected: "b3VtYWU="},
+		"randInt":            {text: `{{ randInt 3 3 }}`, expected: "3"},
+		"unknown variable":   {text: `{{ .unknown }}`, hasErr: true},
+		"invalid randInt":    {text: `{{ randInt 2 1 }}`, hasErr: true},
+		"unknown function":   {text: `{{ foo }}`, hasErr: true},
+		"missing file":       {text: `{{ file "not-found" }}`, hasErr: true},
+		"unclosed action":    {text: `{{ uuid `, hasErr: true},
+		"no template action": {text: `{"name": "kumiko"}`, expected: `{"name": "kumiko"}`},
+	}
+	tmpl := fill.NewTemplate(map[string]string{"name": "kumiko"})
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			actual, err := tmpl.Render(c.text)
+			if c.hasErr {
+				if err == nil {
+					t.Errorf("Render must return an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Render must not return an error, but got '%s'", err)
+			}
+			if actual != c.expected {
+				t.Errorf("expected '%s', but got '%s'", c.expected, actual)
+			}
+		})
+	}
+
+	t.Run("generators return fresh values", func(t *testing.T) {
+		a, err := tmpl.Render(`{{ uuid }} {{ now | rfc3339 }}`)
+		if err != nil {
+			t.Fatalf("Render must not return an error, but got '%s'", err)
+		}
+		b, err := tmpl.Render(`{{ uuid }} {{ now | rfc3339 }}`)
+		if err != nil {
+			t.Fatalf("Render must not return an error, but got '%s'", err)
+		}
+		if a == b {
+			t.Errorf("uuid must return a different value for each rendering, but got '%s' twice", a)
+		}
+	})
+}
+
+func TestTemplateFiller(t *testing.T) {
+	c := &protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: []string{filepath.Join("proto", "testdata")},
+		}),
+	}
+	compiled, err := c.Compile(context.TODO(), "test.proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	md := compiled[0].Messages().ByName(protoreflect.Name("Message"))
+
+	cases := map[string]struct {
+		in string
+
+		expected []string
+		hasErr   bool
+	}{
+		"single message": {
+			in:       `{"p": "{{ .name }}", "j": {{ randInt 1 1 }}}`,
+			expected: []string{"kumiko/1"},
+		},
+		"stream": {
+			in: `{"p": "{{ .name }}-{{ "}" }}"}
+{"p": "{{ printf "%s!" .name }}", "j": 2}  {"p": "\"{{ .name }}\""}`,
+			expected: []string{`kumiko-}/0`, `kumiko!/2`, `"kumiko"/0`},
+		},
+		"whole message action": {
+			in:       `{{ printf "{\"p\": \"%s\"}" .name }} {"j": 3}`,
+			expected: []string{"kumiko/0", "/3"},
+		},
+		"not an object": {
+			in:     `["p"]`,
+			hasErr: true,
+		},
+		"unexpected EOF": {
+			in:     `{"p": "{{ .name }}"`,
+			hasErr: true,
+		},
+		"invalid JSON after rendering": {
+			in:     `{"p": {{ .name }}}`,
+			hasErr: true,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			f := fill.NewTemplateFiller(strings.NewReader(c.in), fill.NewTemplate(map[string]string{"name": "kumiko"}))
+			var actual []string
+			for {
+				msg := dynamicpb.NewMessage(md)
+				err := f.Fill(msg)
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					if !c.hasErr {
+						t.Fatalf("Fill must not return an error, but got '%s'", err)
+					}
+					return
+				}
+				p := msg.Get(md.Fields().ByName("p")).String()
+				j := msg.Get(md.Fields().ByName("j")).Int()
+				actual = append(actual, fmt.Sprintf("%s/%d", p, j))
+			}
+			if c.hasErr {
+				t.Fatalf("Fill must return an error, but got nil")
+			}
+			if diff := cmp.Diff(c.expected, actual); diff != "" {
+				t.Errorf("-want, +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	_ "google.golang.org/genproto/googleapis/rpc/errdetails" // For calling RegisterType.
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/anypb"
 )
@@ -28,7 +29,7 @@ type responseFormatter struct {
 	json        present.Presenter
 	pbMarshaler *jsonpb.Marshaler
 
-	wroteHeader, wroteMessage, wroteTrailer bool
+	wrotePeer, wroteHeader, wroteMessage, wroteTrailer bool
 }
 
 func NewResponseFormatter(w io.Writer, emitDefaults bool) format.ResponseFormatterInterface {
@@ -41,7 +42,24 @@ func NewResponseFormatter(w io.Writer, emitDefaults bool) format.ResponseFormatt
 	}
 }
 
+func (p *responseFormatter) FormatPeer(pr *peer.Peer) {
+	fmt.Fprintf(p.w, "peer: %s\n", pr.Addr)
+	if info := format.NewTLSInfo(pr); info != nil {
+		fmt.Fprintf(p.w, "tls-version: %s\n", info.Version)
+		fmt.Fprintf(p.w, "tls-cipher-suite: %s\n", info.CipherSuite)
+		for _, cert := range info.Certificates {
+			fmt.Fprintf(p.w, "tls-peer-certificate: subject=%q, expiry=%s\n", cert.Subject, cert.Expiry)
+		}
+	}
+
+	p.wrotePeer = true
+}
+
 func (p *responseFormatter) FormatHeader(header metadata.MD) {
+	if p.wrotePeer {
+		fmt.Fprintf(p.w, "\n")
+	}
+
 	var s []string
 	for k, v := range header {
 		for _, vv := range v {
@@ -57,7 +75,7 @@ func (p *responseFormatter) FormatHeader(header metadata.MD) {
 }
 
 func (p *responseFormatter) FormatMessage(v interface{}) error {
-	if p.wroteHeader {
+	if p.wrotePeer || p.wroteHeader {
 		fmt.Fprintf(p.w, "\n")
 	}
 
@@ -81,7 +99,7 @@ func (p *responseFormatter) FormatTrailer(trailer metadata.MD) {
 	if len(trailer) == 0 {
 		return
 	}
-	if p.wroteHeader || p.wroteMessage {
+	if p.wrotePeer || p.wroteHeader || p.wroteMessage {
 		fmt.Fprintf(p.w, "\n")
 	}
 
@@ -102,7 +120,7 @@ func (p *responseFormatter) FormatTrailer(trailer metadata.MD) {
 var replacer = strings.NewReplacer("\n", "", ",", ", ")
 
 func (p *responseFormatter) FormatStatus(status *status.Status) error {
-	if p.wroteHeader || p.wroteMessage || p.wroteTrailer {
+	if p.wrotePeer || p.wroteHeader || p.wroteMessage || p.wroteTrailer {
 		fmt.Fprintf(p.w, "\n")
 	}
 	fmt.Fprintf(p.w, "code: %s\nnumber: %d\nmessage: %q\n", status.Code().String(), status.Code(), status.Message())
@@ -3,6 +3,7 @@ package format
 
 import (
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -24,6 +25,13 @@ func (f *ResponseFormatter) Format(s *status.Status, header, trailer metadata.MD
 	return nil
 }
 
+// FormatPeer formats the peer which served the RPC. It is ignored if p is nil.
+func (f *ResponseFormatter) FormatPeer(p *peer.Peer) {
+	if f.enrich && p != nil {
+		f.impl.FormatPeer(p)
+	}
+}
+
 func (f *ResponseFormatter) FormatHeader(header metadata.MD) {
 	if f.enrich {
 		f.impl.FormatHeader(header)
@@ -60,6 +68,8 @@ func NewResponseFormatter(f ResponseFormatterInterface, enrich bool) *ResponseFo
 
 // ResponseFormatterInterface is an interface for formatting gRPC response.
 type ResponseFormatterInterface interface {
+	// FormatPeer formats the peer which served the RPC.
+	FormatPeer(p *peer.Peer)
 	// FormatHeader formats the response header.
 	FormatHeader(header metadata.MD)
 	// FormatMessage formats the response message (body).
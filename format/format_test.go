@@ -1,16 +1,22 @@
 package format
 
 import (
+	"net"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
 type formatter struct {
-	FormatHeaderCalled, FormatMessageCalled, FormatStatusCalled, FormatTrailerCalled bool
+	FormatPeerCalled, FormatHeaderCalled, FormatMessageCalled, FormatStatusCalled, FormatTrailerCalled bool
+}
+
+func (f *formatter) FormatPeer(p *peer.Peer) {
+	f.FormatPeerCalled = true
 }
 
 func (f *formatter) FormatHeader(header metadata.MD) {
@@ -48,6 +54,7 @@ func TestResponseFormatter(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			impl := &formatter{}
 			f := NewResponseFormatter(impl, c.enrich)
+			f.FormatPeer(&peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 50051}})
 			f.FormatHeader(metadata.Pairs("key", "val"))
 			if err := f.FormatMessage(struct{}{}); err != nil {
 				t.Fatalf("FormatMessage should not return an error, but got '%s'", err)
@@ -67,6 +74,9 @@ func TestResponseFormatter(t *testing.T) {
 			if called, ok := res[c.enrich]; ok && !called {
 				t.Errorf("expected true, but false")
 			}
+			if impl.FormatPeerCalled != c.enrich {
+				t.Errorf("FormatPeer must be called only if enrich is true")
+			}
 
 			t.Run("Format", func(t *testing.T) {
 				impl := &formatter{}
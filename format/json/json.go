@@ -14,6 +14,7 @@ import (
 	"github.com/pkg/errors"
 	_ "google.golang.org/genproto/googleapis/rpc/errdetails" // For calling RegisterType.
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/anypb"
 )
@@ -28,6 +29,10 @@ type responseFormatter struct {
 			Message string        `json:"message"`
 			Details []interface{} `json:"details,omitempty"`
 		} `json:"status,omitempty"`
+		Peer *struct {
+			Address string          `json:"address"`
+			TLS     *format.TLSInfo `json:"tls,omitempty"`
+		} `json:"peer,omitempty"`
 		Header   *metadata.MD             `json:"header,omitempty"`
 		Messages []map[string]interface{} `json:"messages,omitempty"`
 		Trailer  *metadata.MD             `json:"trailer,omitempty"`
@@ -42,6 +47,13 @@ func NewResponseFormatter(w io.Writer, emitDefaults bool) format.ResponseFormatt
 	}}
 }
 
+func (p *responseFormatter) FormatPeer(pr *peer.Peer) {
+	p.s.Peer = &struct {
+		Address string          `json:"address"`
+		TLS     *format.TLSInfo `json:"tls,omitempty"`
+	}{Address: pr.Addr.String(), TLS: format.NewTLSInfo(pr)}
+}
+
 func (p *responseFormatter) FormatHeader(header metadata.MD) {
 	p.s.Header = &header
 }
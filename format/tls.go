@@ -0,0 +1,59 @@
+package format
+
+import (
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// TLSInfo describes the TLS connection with the peer.
+type TLSInfo struct {
+	Version      string            `json:"version"`
+	CipherSuite  string            `json:"cipherSuite"`
+	Certificates []CertificateInfo `json:"certificates"`
+}
+
+// CertificateInfo describes a certificate in the peer certificate chain.
+type CertificateInfo struct {
+	Subject string `json:"subject"`
+	Expiry  string `json:"expiry"`
+}
+
+// NewTLSInfo returns the TLS connection information of p. It returns nil if the connection is not secure.
+func NewTLSInfo(p *peer.Peer) *TLSInfo {
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	s := info.State
+	certs := make([]CertificateInfo, 0, len(s.PeerCertificates))
+	for _, cert := range s.PeerCertificates {
+		certs = append(certs, CertificateInfo{
+			Subject: cert.Subject.String(),
+			Expiry:  cert.NotAfter.UTC().Format(time.RFC3339),
+		})
+	}
+	return &TLSInfo{
+		Version:      tlsVersionName(s.Version),
+		CipherSuite:  tls.CipherSuiteName(s.CipherSuite),
+		Certificates: certs,
+	}
+}
+
+func tlsVersionName(v uint16) string {
+	switch v {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("0x%04X", v)
+	}
+}
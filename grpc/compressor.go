@@ -1,3 +1,130 @@
 package grpc
 
-import _ "google.golang.org/grpc/encoding/gzip" // GZIP
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // GZIP
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+)
+
+func init() {
+	encoding.RegisterCompressor(&zstdCompressor{})
+	encoding.RegisterCompressor(&snappyCompressor{})
+}
+
+type zstdCompressor struct{}
+
+func (c *zstdCompressor) Name() string { return "zstd" }
+
+func (c *zstdCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+}
+
+func (c *zstdCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return &zstdReader{d}, nil
+}
+
+// zstdReader releases resources of the decoder when it reaches to EOF.
+type zstdReader struct {
+	*zstd.Decoder
+}
+
+func (r *zstdReader) Read(p []byte) (int, error) {
+	n, err := r.Decoder.Read(p)
+	if err == io.EOF {
+		r.Decoder.Close()
+	}
+	return n, err
+}
+
+// snappyCompressor uses the snappy framing format.
+type snappyCompressor struct{}
+
+func (c *snappyCompressor) Name() string { return "snappy" }
+
+func (c *snappyCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	return snappy.NewBufferedWriter(w), nil
+}
+
+func (c *snappyCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	return snappy.NewReader(r), nil
+}
+
+type compressorKey struct{}
+
+// NewContextWithCompressor returns a new context that has the compressor name.
+// Requests sent with the context are compressed by the compressor.
+func NewContextWithCompressor(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, compressorKey{}, name)
+}
+
+func compressorFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(compressorKey{}).(string)
+	return name
+}
+
+// responseEncoding holds the encoding of a response.
+type responseEncoding struct {
+	mu  sync.Mutex
+	enc string
+}
+
+type responseEncodingKey struct{}
+
+func withResponseEncoding(ctx context.Context) context.Context {
+	return context.WithValue(ctx, responseEncodingKey{}, &responseEncoding{})
+}
+
+// withEncodingHeader returns a copy of header with the grpc-encoding header which is received from the server.
+// gRPC removes the header from response headers, so it is restored from the value recorded by encodingStatsHandler.
+func withEncodingHeader(ctx context.Context, header metadata.MD) metadata.MD {
+	re, ok := ctx.Value(responseEncodingKey{}).(*responseEncoding)
+	if !ok {
+		return header
+	}
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	if re.enc == "" {
+		return header
+	}
+	header = header.Copy()
+	header.Set("grpc-encoding", re.enc)
+	return header
+}
+
+// encodingStatsHandler records the encoding of responses.
+type encodingStatsHandler struct{}
+
+func (encodingStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (encodingStatsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	in, ok := s.(*stats.InHeader)
+	if !ok || !in.Client {
+		return
+	}
+	re, ok := ctx.Value(responseEncodingKey{}).(*responseEncoding)
+	if !ok {
+		return
+	}
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.enc = in.Compression
+}
+
+func (encodingStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (encodingStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/encoding"
+)
+
+func TestCompressors(t *testing.T) {
+	in := strings.Repeat("kumiko", 1024)
+	for _, name := range []string{"gzip", "zstd", "snappy"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			c := encoding.GetCompressor(name)
+			if c == nil {
+				t.Fatalf("compressor '%s' must be registered", name)
+			}
+
+			var buf bytes.Buffer
+			w, err := c.Compress(&buf)
+			if err != nil {
+				t.Fatalf("Compress must not return an error, but got '%s'", err)
+			}
+			if _, err := io.WriteString(w, in); err != nil {
+				t.Fatalf("Write must not return an error, but got '%s'", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close must not return an error, but got '%s'", err)
+			}
+			if buf.Len() >= len(in) {
+				t.Errorf("the compressed message must be smaller than the original, but got %d bytes", buf.Len())
+			}
+
+			r, err := c.Decompress(&buf)
+			if err != nil {
+				t.Fatalf("Decompress must not return an error, but got '%s'", err)
+			}
+			out, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("Read must not return an error, but got '%s'", err)
+			}
+			if string(out) != in {
+				t.Errorf("the decompressed message must be equal to the original")
+			}
+		})
+	}
+}
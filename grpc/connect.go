@@ -0,0 +1,596 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ktr0731/evans/grpc/grpcreflection"
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding"
+	protoenc "google.golang.org/grpc/encoding/proto"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+type connectClient struct {
+	conn    *connectConn
+	headers Headers
+
+	grpcreflection.Client
+}
+
+// NewConnectClient creates a new client for Connect protocol servers. Like NewWebClient, addr must be formed "host:port".
+// The rest of arguments are the same as NewClient's.
+//
+// Unary, client streaming and server streaming RPCs are sent over HTTP/1.1 or HTTP/2.
+// Bidi streaming RPCs (including gRPC reflection) require HTTP/2. If useTLS is false, HTTP/2 without TLS (h2c) is used.
+func NewConnectClient(addr, serverName string, useReflection, useTLS bool, cacert, cert, certKey string, headers Headers, opts ...Option) (Client, error) {
+	o := newOpt(opts)
+	var tlsCfg *tls.Config
+	if useTLS {
+		var err error
+		tlsCfg, err = newTLSConfig(serverName, cacert, cert, certKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := o.configureTLS(tlsCfg); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := newConnectConn(addr, tlsCfg, o)
+	if err != nil {
+		return nil, err
+	}
+	client := &connectClient{
+		conn:    conn,
+		headers: Headers{},
+	}
+
+	if useReflection {
+		client.Client = grpcreflection.NewClient(conn, headers)
+	}
+
+	return client, nil
+}
+
+func (c *connectClient) Invoke(ctx context.Context, fqrn string, req, res interface{}) (header, trailer metadata.MD, _ error) {
+	if compressorFromContext(ctx) != "" {
+		return nil, nil, ErrCompressionNotSupported
+	}
+	endpoint, err := fqrnToEndpoint(fqrn)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "connect: failed to convert FQRN to endpoint")
+	}
+
+	loggingRequest(req)
+
+	err = c.conn.Invoke(ctx, endpoint, req, res, grpc.Header(&header), grpc.Trailer(&trailer))
+	return header, trailer, errors.Wrap(err, "connect: failed to send a request")
+}
+
+func (c *connectClient) NewClientStream(ctx context.Context, streamDesc *grpc.StreamDesc, fqrn string) (ClientStream, error) {
+	if compressorFromContext(ctx) != "" {
+		return nil, ErrCompressionNotSupported
+	}
+	endpoint, err := fqrnToEndpoint(fqrn)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert FQRN to endpoint")
+	}
+	cs, err := c.conn.NewStream(ctx, streamDesc, endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a new stream")
+	}
+	return &clientStream{cs}, nil
+}
+
+func (c *connectClient) NewServerStream(ctx context.Context, streamDesc *grpc.StreamDesc, fqrn string) (ServerStream, error) {
+	s, err := c.NewClientStream(ctx, streamDesc, fqrn)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create server stream")
+	}
+	return &serverStream{s.(*clientStream)}, nil
+}
+
+func (c *connectClient) NewBidiStream(ctx context.Context, streamDesc *grpc.StreamDesc, fqrn string) (BidiStream, error) {
+	s, err := c.NewServerStream(ctx, streamDesc, fqrn)
+	if err != nil {
+		return nil, err
+	}
+	return &bidiStream{s.(*serverStream)}, nil
+}
+
+func (c *connectClient) Close(ctx context.Context) error {
+	if c.Client != nil {
+		c.Client.Reset()
+	}
+	c.conn.close()
+	return nil
+}
+
+func (c *connectClient) Header() Headers {
+	return c.headers
+}
+
+const (
+	connectFlagCompressed = 0x01
+	connectFlagEndStream  = 0x02
+)
+
+// connectConn is a connection to a Connect protocol server.
+// It implements grpc.ClientConnInterface, so the stream implementations and the gRPC reflection client for gRPC
+// are also available for Connect protocol.
+type connectConn struct {
+	baseURL   string
+	codec     encoding.Codec
+	userAgent string
+	// creds provides request metadata attached to each request. It may be nil.
+	creds credentials.PerRPCCredentials
+
+	// client is used for unary, client streaming and server streaming RPCs.
+	client *http.Client
+	// duplexClient is used for bidi streaming RPCs. It always uses HTTP/2.
+	duplexClient *http.Client
+}
+
+func newConnectConn(addr string, tlsCfg *tls.Config, o *opt) (*connectConn, error) {
+	dial, err := o.dialer()
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg == nil {
+		return &connectConn{
+			baseURL:   "http://" + addr,
+			codec:     encoding.GetCodec(protoenc.Name),
+			userAgent: o.userAgent,
+			creds:     o.creds,
+			client: &http.Client{
+				Transport: &http.Transport{Proxy: o.httpProxy(), DialContext: dial},
+			},
+			duplexClient: &http.Client{
+				Transport: &http2.Transport{
+					AllowHTTP: true,
+					DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+						return dial(ctx, network, addr)
+					},
+				},
+			},
+		}, nil
+	}
+	return &connectConn{
+		baseURL:   "https://" + addr,
+		codec:     encoding.GetCodec(protoenc.Name),
+		userAgent: o.userAgent,
+		creds:     o.creds,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:             o.httpProxy(),
+				DialContext:       dial,
+				TLSClientConfig:   tlsCfg,
+				ForceAttemptHTTP2: true,
+			},
+		},
+		duplexClient: &http.Client{
+			Transport: &http2.Transport{
+				TLSClientConfig: tlsCfg,
+				DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+					conn, err := dial(ctx, network, addr)
+					if err != nil {
+						return nil, err
+					}
+					tlsConn := tls.Client(conn, cfg)
+					if err := tlsConn.HandshakeContext(ctx); err != nil {
+						conn.Close()
+						return nil, err
+					}
+					return tlsConn, nil
+				},
+			},
+		},
+	}, nil
+}
+
+// Invoke sends a unary RPC. Header and trailer call options are supported, other options are ignored.
+func (c *connectConn) Invoke(ctx context.Context, method string, args, reply interface{}, opts ...grpc.CallOption) error {
+	b, err := c.codec.Marshal(args)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to marshal the request: %s", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+method, bytes.NewReader(b))
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to build the request: %s", err)
+	}
+	if err := c.setRequestHeader(ctx, req.Header, req.URL.String()); err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/proto")
+	req.Header.Set("Connect-Protocol-Version", "1")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return connectTransportError(ctx, err)
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return connectTransportError(ctx, err)
+	}
+
+	// Unary RPCs send trailers as headers prefixed with "Trailer-".
+	header, trailer := metadata.MD{}, metadata.MD{}
+	for k, v := range toConnectMetadata(res.Header) {
+		if strings.HasPrefix(k, "trailer-") {
+			trailer[strings.TrimPrefix(k, "trailer-")] = v
+		} else {
+			header[k] = v
+		}
+	}
+	for _, o := range opts {
+		switch o := o.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = header
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = trailer
+		}
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return connectErrorFromBody(res.StatusCode, body)
+	}
+	if err := c.codec.Unmarshal(body, reply); err != nil {
+		return status.Errorf(codes.Internal, "failed to unmarshal the response: %s", err)
+	}
+	return nil
+}
+
+// NewStream creates a new stream. The request is sent in the background, and messages are written to its body.
+func (c *connectConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	pr, pw := io.Pipe()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+method, pr)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build the request: %s", err)
+	}
+	if err := c.setRequestHeader(ctx, req.Header, req.URL.String()); err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	req.Header.Set("Content-Type", "application/connect+proto")
+
+	client := c.client
+	if desc.ClientStreams && desc.ServerStreams {
+		client = c.duplexClient
+	}
+
+	s := &connectStream{
+		ctx:   ctx,
+		desc:  desc,
+		codec: c.codec,
+		pw:    pw,
+		done:  make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		s.res, s.resErr = client.Do(req)
+	}()
+	return s, nil
+}
+
+// setRequestHeader sets headers common to all requests.
+func (c *connectConn) setRequestHeader(ctx context.Context, h http.Header, uri string) error {
+	setConnectRequestHeader(ctx, h)
+	if c.userAgent != "" {
+		h.Set("User-Agent", c.userAgent)
+	}
+	return setPerRPCCredentials(ctx, c.creds, h, uri)
+}
+
+func (c *connectConn) close() {
+	c.client.CloseIdleConnections()
+	c.duplexClient.CloseIdleConnections()
+}
+
+// connectStream implements grpc.ClientStream for Connect protocol.
+type connectStream struct {
+	ctx   context.Context
+	desc  *grpc.StreamDesc
+	codec encoding.Codec
+	pw    *io.PipeWriter
+
+	// done is closed when the response headers are received or the request failed.
+	done   chan struct{}
+	res    *http.Response
+	resErr error
+
+	once    sync.Once
+	header  metadata.MD
+	trailer metadata.MD
+	// err is the result of the response headers.
+	err error
+
+	// finished reports whether the end-stream message has been received.
+	finished bool
+	// endErr is the error contained in the end-stream message.
+	endErr error
+}
+
+func (s *connectStream) Header() (metadata.MD, error) {
+	if err := s.waitResponse(); err != nil {
+		return nil, err
+	}
+	return s.header, nil
+}
+
+// Trailer returns the metadata contained in the end-stream message.
+func (s *connectStream) Trailer() metadata.MD {
+	return s.trailer
+}
+
+func (s *connectStream) CloseSend() error {
+	return s.pw.Close()
+}
+
+func (s *connectStream) Context() context.Context {
+	return s.ctx
+}
+
+// SendMsg sends a message. Like gRPC, SendMsg returns io.EOF if the stream is broken.
+// The actual error can be got by RecvMsg.
+func (s *connectStream) SendMsg(m interface{}) error {
+	b, err := s.codec.Marshal(m)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to marshal the request: %s", err)
+	}
+	if _, err := s.pw.Write(connectEnvelope(0, b)); err != nil {
+		return io.EOF
+	}
+	// Like gRPC, close the send direction automatically if the RPC accepts only one request.
+	if !s.desc.ClientStreams {
+		return s.pw.Close()
+	}
+	return nil
+}
+
+func (s *connectStream) RecvMsg(m interface{}) error {
+	b, err := s.recv()
+	if err != nil {
+		return err
+	}
+	if err := s.codec.Unmarshal(b, m); err != nil {
+		return status.Errorf(codes.Internal, "failed to unmarshal the response: %s", err)
+	}
+	if !s.desc.ServerStreams {
+		// Like gRPC, receive the end-stream message to get the status of the RPC.
+		if _, err := s.recv(); err != io.EOF {
+			if err == nil {
+				return status.Error(codes.Internal, "received multiple responses from a non server streaming RPC")
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// recv receives the next message. It returns io.EOF if the stream finished successfully.
+func (s *connectStream) recv() ([]byte, error) {
+	if err := s.waitResponse(); err != nil {
+		return nil, err
+	}
+	if s.finished {
+		if s.endErr != nil {
+			return nil, s.endErr
+		}
+		return nil, io.EOF
+	}
+
+	var h [5]byte
+	if _, err := io.ReadFull(s.res.Body, h[:]); err != nil {
+		return nil, s.readError(err)
+	}
+	b := make([]byte, binary.BigEndian.Uint32(h[1:]))
+	if _, err := io.ReadFull(s.res.Body, b); err != nil {
+		return nil, s.readError(err)
+	}
+
+	if h[0]&connectFlagEndStream != 0 {
+		s.finished = true
+		s.res.Body.Close()
+		s.trailer, s.endErr = parseConnectEndStream(b)
+		if s.endErr != nil {
+			return nil, s.endErr
+		}
+		return nil, io.EOF
+	}
+	if h[0]&connectFlagCompressed != 0 {
+		return nil, status.Error(codes.Internal, "compressed messages are not supported")
+	}
+	return b, nil
+}
+
+func (s *connectStream) readError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		if s.ctx.Err() != nil {
+			return status.FromContextError(s.ctx.Err()).Err()
+		}
+		return status.Error(codes.Internal, "the server closed the stream without the end-stream message")
+	}
+	return connectTransportError(s.ctx, err)
+}
+
+// waitResponse waits for the response headers.
+func (s *connectStream) waitResponse() error {
+	<-s.done
+	s.once.Do(func() {
+		if s.resErr != nil {
+			s.pw.CloseWithError(s.resErr)
+			s.err = connectTransportError(s.ctx, s.resErr)
+			return
+		}
+		s.header = toConnectMetadata(s.res.Header)
+		if s.res.StatusCode != http.StatusOK {
+			defer s.res.Body.Close()
+			body, _ := io.ReadAll(s.res.Body)
+			s.err = connectErrorFromBody(s.res.StatusCode, body)
+		}
+	})
+	return s.err
+}
+
+// connectEnvelope returns an enveloped message.
+func connectEnvelope(flags byte, b []byte) []byte {
+	buf := make([]byte, 5+len(b))
+	buf[0] = flags
+	binary.BigEndian.PutUint32(buf[1:], uint32(len(b)))
+	copy(buf[5:], b)
+	return buf
+}
+
+// setConnectRequestHeader sets outgoing metadata and the timeout of ctx to h.
+func setConnectRequestHeader(ctx context.Context, h http.Header) {
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for k, v := range md {
+		for _, vv := range v {
+			if strings.HasSuffix(k, "-bin") {
+				vv = base64.RawStdEncoding.EncodeToString([]byte(vv))
+			}
+			h.Add(k, vv)
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		ms := time.Until(deadline).Milliseconds()
+		if ms < 1 {
+			ms = 1
+		}
+		h.Set("Connect-Timeout-Ms", strconv.FormatInt(ms, 10))
+	}
+}
+
+// toConnectMetadata converts HTTP headers to metadata. Binary values are decoded.
+func toConnectMetadata(h http.Header) metadata.MD {
+	md := metadata.MD{}
+	for k, v := range h {
+		k = strings.ToLower(k)
+		for _, vv := range v {
+			if strings.HasSuffix(k, "-bin") {
+				if b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(vv, "=")); err == nil {
+					vv = string(b)
+				}
+			}
+			md.Append(k, vv)
+		}
+	}
+	return md
+}
+
+// connectError represents an error of Connect protocol.
+type connectError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details []struct {
+		Type  string `json:"type"`
+		Value string `json:"value"`
+	} `json:"details"`
+}
+
+var connectCodes = map[string]codes.Code{
+	"canceled":            codes.Canceled,
+	"unknown":             codes.Unknown,
+	"invalid_argument":    codes.InvalidArgument,
+	"deadline_exceeded":   codes.DeadlineExceeded,
+	"not_found":           codes.NotFound,
+	"already_exists":      codes.AlreadyExists,
+	"permission_denied":   codes.PermissionDenied,
+	"resource_exhausted":  codes.ResourceExhausted,
+	"failed_precondition": codes.FailedPrecondition,
+	"aborted":             codes.Aborted,
+	"out_of_range":        codes.OutOfRange,
+	"unimplemented":       codes.Unimplemented,
+	"internal":            codes.Internal,
+	"unavailable":         codes.Unavailable,
+	"data_loss":           codes.DataLoss,
+	"unauthenticated":     codes.Unauthenticated,
+}
+
+// status converts e to *status.Status. Details which cannot be decoded are ignored.
+func (e *connectError) status() *status.Status {
+	code, ok := connectCodes[e.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+	s := &spb.Status{Code: int32(code), Message: e.Message}
+	for _, d := range e.Details {
+		b, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(d.Value, "="))
+		if err != nil {
+			continue
+		}
+		s.Details = append(s.Details, &anypb.Any{TypeUrl: "type.googleapis.com/" + d.Type, Value: b})
+	}
+	return status.FromProto(s)
+}
+
+// connectErrorFromBody returns an error from a non-200 response.
+// If body is not a Connect error, the code is inferred from the HTTP status code.
+func connectErrorFromBody(statusCode int, body []byte) error {
+	var e connectError
+	if err := json.Unmarshal(body, &e); err == nil && e.Code != "" {
+		return e.status().Err()
+	}
+	return status.Error(connectCodeFromHTTPStatus(statusCode), http.StatusText(statusCode))
+}
+
+func connectCodeFromHTTPStatus(statusCode int) codes.Code {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return codes.Internal
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.Unimplemented
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return codes.Unavailable
+	default:
+		return codes.Unknown
+	}
+}
+
+// parseConnectEndStream parses the payload of an end-stream message.
+func parseConnectEndStream(b []byte) (metadata.MD, error) {
+	var msg struct {
+		Error    *connectError       `json:"error"`
+		Metadata map[string][]string `json:"metadata"`
+	}
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to parse the end-stream message: %s", err)
+	}
+	trailer := toConnectMetadata(msg.Metadata)
+	if msg.Error != nil {
+		return trailer, msg.Error.status().Err()
+	}
+	return trailer, nil
+}
+
+// connectTransportError converts an error of the HTTP client to a gRPC status error.
+func connectTransportError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return status.Error(codes.Unavailable, err.Error())
+}
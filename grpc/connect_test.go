@@ -0,0 +1,255 @@
+package grpc_test
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ktr0731/evans/grpc"
+	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestConnectClient_Invoke(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/grpc.health.v1.Health/Check", func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/proto" {
+			t.Errorf("unexpected content-type: %s", ct)
+		}
+		var req healthpb.HealthCheckRequest
+		b, _ := io.ReadAll(r.Body)
+		if err := proto.Unmarshal(b, &req); err != nil {
+			t.Errorf("failed to unmarshal the request: %s", err)
+		}
+		switch req.Service {
+		case "ok":
+			b, _ := proto.Marshal(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+			w.Header().Set("Content-Type", "application/proto")
+			w.Header().Set("X-Header", r.Header.Get("X-Request"))
+			w.Header().Set("Trailer-X-Trailer", "bar")
+			w.Write(b) //nolint:errcheck
+		case "error":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"code": "not_found", "message": "service not found"}`) //nolint:errcheck
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client, err := grpc.NewConnectClient(srv.Listener.Addr().String(), "", false, false, "", "", "", nil)
+	if err != nil {
+		t.Fatalf("NewConnectClient must not return an error, but got '%s'", err)
+	}
+	defer client.Close(context.Background())
+
+	cases := map[string]struct {
+		service string
+
+		expectedCode    codes.Code
+		expectedMessage string
+	}{
+		"ok":                      {service: "ok", expectedCode: codes.OK},
+		"connect error":           {service: "error", expectedCode: codes.NotFound, expectedMessage: "service not found"},
+		"HTTP error without body": {service: "unavailable", expectedCode: codes.Unavailable, expectedMessage: "Service Unavailable"},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request", "foo")
+			var res healthpb.HealthCheckResponse
+			header, trailer, err := client.Invoke(ctx, "grpc.health.v1.Health.Check", &healthpb.HealthCheckRequest{Service: c.service}, &res)
+			if c.expectedCode != codes.OK {
+				stat, ok := status.FromError(errors.Cause(err))
+				if !ok {
+					t.Fatalf("Invoke must return a status error, but got '%v'", err)
+				}
+				if stat.Code() != c.expectedCode || stat.Message() != c.expectedMessage {
+					t.Errorf("unexpected status: %s", stat)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Invoke must not return an error, but got '%s'", err)
+			}
+			if res.Status != healthpb.HealthCheckResponse_SERVING {
+				t.Errorf("expected SERVING, but got %s", res.Status)
+			}
+			if diff := cmp.Diff([]string{"foo"}, header.Get("x-header")); diff != "" {
+				t.Errorf("-want, +got\n%s", diff)
+			}
+			if diff := cmp.Diff(metadata.Pairs("x-trailer", "bar"), trailer); diff != "" {
+				t.Errorf("-want, +got\n%s", diff)
+			}
+		})
+	}
+}
+
+type credentialsFunc func() (map[string]string, error)
+
+func (f credentialsFunc) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return f()
+}
+
+func (f credentialsFunc) RequireTransportSecurity() bool { return false }
+
+// secureCredentialsFunc is the same as credentialsFunc, but it requires transport security.
+type secureCredentialsFunc func() (map[string]string, error)
+
+func (f secureCredentialsFunc) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return f()
+}
+
+func (f secureCredentialsFunc) RequireTransportSecurity() bool { return true }
+
+func TestConnectClient_PerRPCCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer kumiko" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"code": "unauthenticated"}`) //nolint:errcheck
+			return
+		}
+		b, _ := proto.Marshal(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+		w.Header().Set("Content-Type", "application/proto")
+		w.Write(b) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	cases := map[string]struct {
+		creds credentials.PerRPCCredentials
+
+		expectedCode codes.Code
+	}{
+		"ok": {
+			creds:        credentialsFunc(func() (map[string]string, error) { return map[string]string{"authorization": "Bearer kumiko"}, nil }),
+			expectedCode: codes.OK,
+		},
+		"credentials error": {
+			creds:        credentialsFunc(func() (map[string]string, error) { return nil, errors.New("token expired") }),
+			expectedCode: codes.Unauthenticated,
+		},
+		"credentials require transport security": {
+			creds:        secureCredentialsFunc(func() (map[string]string, error) { return map[string]string{"authorization": "Bearer kumiko"}, nil }),
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			client, err := grpc.NewConnectClient(srv.Listener.Addr().String(), "", false, false, "", "", "", nil, grpc.WithPerRPCCredentials(c.creds))
+			if err != nil {
+				t.Fatalf("NewConnectClient must not return an error, but got '%s'", err)
+			}
+			defer client.Close(context.Background())
+
+			var res healthpb.HealthCheckResponse
+			_, _, err = client.Invoke(context.Background(), "grpc.health.v1.Health.Check", &healthpb.HealthCheckRequest{}, &res)
+			if code := status.Code(errors.Cause(err)); code != c.expectedCode {
+				t.Errorf("expected %s, but got '%v'", c.expectedCode, err)
+			}
+		})
+	}
+}
+
+func TestConnectClient_Stream(t *testing.T) {
+	// The handler echoes each request as a response, then finishes the stream with an error.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "application/connect+proto" {
+			t.Errorf("unexpected content-type: %s", ct)
+		}
+		w.Header().Set("Content-Type", "application/connect+proto")
+		w.WriteHeader(http.StatusOK)
+		for {
+			var h [5]byte
+			if _, err := io.ReadFull(r.Body, h[:]); err != nil {
+				break
+			}
+			b := make([]byte, binary.BigEndian.Uint32(h[1:]))
+			if _, err := io.ReadFull(r.Body, b); err != nil {
+				t.Errorf("failed to read a message: %s", err)
+				return
+			}
+			var req healthpb.HealthCheckRequest
+			if err := proto.Unmarshal(b, &req); err != nil {
+				t.Errorf("failed to unmarshal the request: %s", err)
+				return
+			}
+			b, _ = proto.Marshal(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+			w.Write(append(h[:1], append(binary.BigEndian.AppendUint32(nil, uint32(len(b))), b...)...)) //nolint:errcheck
+			w.(http.Flusher).Flush()
+		}
+		end := []byte(`{"error": {"code": "unavailable", "message": "bye"}, "metadata": {"x-trailer": ["bar"]}}`)
+		w.Write(append([]byte{0x02}, append(binary.BigEndian.AppendUint32(nil, uint32(len(end))), end...)...)) //nolint:errcheck
+	})
+	srv := httptest.NewServer(h2c.NewHandler(handler, &http2.Server{}))
+	defer srv.Close()
+
+	client, err := grpc.NewConnectClient(srv.Listener.Addr().String(), "", false, false, "", "", "", nil)
+	if err != nil {
+		t.Fatalf("NewConnectClient must not return an error, but got '%s'", err)
+	}
+	defer client.Close(context.Background())
+
+	assertEnd := func(t *testing.T, err error, trailer metadata.MD) {
+		t.Helper()
+		if stat := status.Convert(errors.Cause(err)); stat.Code() != codes.Unavailable || stat.Message() != "bye" {
+			t.Errorf("unexpected status: %s", stat)
+		}
+		if diff := cmp.Diff(metadata.Pairs("x-trailer", "bar"), trailer); diff != "" {
+			t.Errorf("-want, +got\n%s", diff)
+		}
+	}
+
+	t.Run("server stream", func(t *testing.T) {
+		stream, err := client.NewServerStream(context.Background(), &gogrpc.StreamDesc{ServerStreams: true}, "grpc.health.v1.Health.Watch")
+		if err != nil {
+			t.Fatalf("NewServerStream must not return an error, but got '%s'", err)
+		}
+		if err := stream.Send(&healthpb.HealthCheckRequest{}); err != nil {
+			t.Fatalf("Send must not return an error, but got '%s'", err)
+		}
+		var res healthpb.HealthCheckResponse
+		if err := stream.Receive(&res); err != nil {
+			t.Fatalf("Receive must not return an error, but got '%s'", err)
+		}
+		err = stream.Receive(&res)
+		assertEnd(t, err, stream.Trailer())
+	})
+
+	t.Run("bidi stream", func(t *testing.T) {
+		stream, err := client.NewBidiStream(context.Background(), &gogrpc.StreamDesc{ClientStreams: true, ServerStreams: true}, "grpc.health.v1.Health.Watch")
+		if err != nil {
+			t.Fatalf("NewBidiStream must not return an error, but got '%s'", err)
+		}
+		for i := 0; i < 2; i++ {
+			if err := stream.Send(&healthpb.HealthCheckRequest{}); err != nil {
+				t.Fatalf("Send must not return an error, but got '%s'", err)
+			}
+			var res healthpb.HealthCheckResponse
+			if err := stream.Receive(&res); err != nil {
+				t.Fatalf("Receive must not return an error, but got '%s'", err)
+			}
+		}
+		if err := stream.CloseSend(); err != nil {
+			t.Fatalf("CloseSend must not return an error, but got '%s'", err)
+		}
+		var res healthpb.HealthCheckResponse
+		err = stream.Receive(&res)
+		assertEnd(t, err, stream.Trailer())
+	})
+}
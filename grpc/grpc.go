@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"strings"
-	"time"
 
 	"crypto/tls"
 	"crypto/x509"
@@ -21,10 +21,14 @@ import (
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 )
 
 var ErrMutualAuthParamsAreNotEnough = errors.New("cert and certkey are required to authenticate mutually")
 
+// ErrCompressionNotSupported is returned if request compression is specified for gRPC-Web or Connect protocol.
+var ErrCompressionNotSupported = errors.New("request compression is available for gRPC only")
+
 // RPC represents a RPC which belongs to a gRPC service.
 type RPC struct {
 	Name               string
@@ -115,44 +119,44 @@ type client struct {
 // The set of cert and certKey enables mutual authentication if useTLS is enabled.
 // If one of it is not found, NewClient returns ErrMutualAuthParamsAreNotEnough.
 // If useTLS is false, cacert, cert and certKey are ignored.
-func NewClient(addr, serverName string, useReflection, useTLS bool, cacert, cert, certKey string, headers map[string][]string) (Client, error) {
-	var opts []grpc.DialOption
+// opts modifies transport settings such as the dial timeout and keepalive.
+func NewClient(addr, serverName string, useReflection, useTLS bool, cacert, cert, certKey string, headers map[string][]string, opts ...Option) (Client, error) {
+	o := newOpt(opts)
+	dialOpts := append(o.dialOptions(), grpc.WithStatsHandler(encodingStatsHandler{}))
 	if !useTLS {
-		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	} else { // Enable TLS authentication
-		var tlsCfg tls.Config
-		if cacert != "" {
-			b, err := os.ReadFile(cacert)
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to read the CA certificate")
-			}
-			cp := x509.NewCertPool()
-			if !cp.AppendCertsFromPEM(b) {
-				return nil, errors.New("failed to append the client certificate")
-			}
-			tlsCfg.RootCAs = cp
+		tlsCfg, err := newTLSConfig("", cacert, cert, certKey)
+		if err != nil {
+			return nil, err
 		}
-		if cert != "" && certKey != "" {
-			// Enable mutual authentication
-			certificate, err := tls.LoadX509KeyPair(cert, certKey)
-			if err != nil {
-				return nil, errors.Wrap(err, "failed to read the client certificate")
-			}
-			tlsCfg.Certificates = append(tlsCfg.Certificates, certificate)
-		} else if cert != "" || certKey != "" {
-			return nil, ErrMutualAuthParamsAreNotEnough
+		if err := o.configureTLS(tlsCfg); err != nil {
+			return nil, err
 		}
 
-		creds := credentials.NewTLS(&tlsCfg)
-		opts = append(opts, grpc.WithTransportCredentials(creds))
+		creds := credentials.NewTLS(tlsCfg)
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(creds))
 
 		if serverName != "" {
-			opts = append(opts, grpc.WithAuthority(serverName))
+			dialOpts = append(dialOpts, grpc.WithAuthority(serverName))
+		}
+	}
+	if o.proxy != nil {
+		dial, err := o.dialer()
+		if err != nil {
+			return nil, err
 		}
+		dialOpts = append(dialOpts, grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return dial(ctx, "tcp", addr)
+		}))
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 7*time.Second)
-	defer cancel()
-	conn, err := grpc.DialContext(ctx, addr, opts...)
+	ctx := context.Background()
+	if o.dialTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.dialTimeout)
+		defer cancel()
+	}
+	conn, err := grpc.DialContext(ctx, addr, dialOpts...)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to dial to gRPC server")
 	}
@@ -169,6 +173,37 @@ func NewClient(addr, serverName string, useReflection, useTLS bool, cacert, cert
 	return client, nil
 }
 
+// newTLSConfig returns a TLS config for connecting to the server.
+// If serverName is not empty, it is used to verify the hostname on the returned certificates.
+// If cacert is not empty, it is used as the root CA instead of the system one.
+// The set of cert and certKey enables mutual authentication. If one of it is not found,
+// newTLSConfig returns ErrMutualAuthParamsAreNotEnough.
+func newTLSConfig(serverName, cacert, cert, certKey string) (*tls.Config, error) {
+	tlsCfg := tls.Config{ServerName: serverName}
+	if cacert != "" {
+		b, err := os.ReadFile(cacert)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the CA certificate")
+		}
+		cp := x509.NewCertPool()
+		if !cp.AppendCertsFromPEM(b) {
+			return nil, errors.New("failed to append the client certificate")
+		}
+		tlsCfg.RootCAs = cp
+	}
+	if cert != "" && certKey != "" {
+		// Enable mutual authentication
+		certificate, err := tls.LoadX509KeyPair(cert, certKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read the client certificate")
+		}
+		tlsCfg.Certificates = append(tlsCfg.Certificates, certificate)
+	} else if cert != "" || certKey != "" {
+		return nil, ErrMutualAuthParamsAreNotEnough
+	}
+	return &tlsCfg, nil
+}
+
 func (c *client) Invoke(ctx context.Context, fqrn string, req, res interface{}) (header, trailer metadata.MD, _ error) {
 	logger.Scriptln(func() []interface{} {
 		md, ok := metadata.FromOutgoingContext(ctx)
@@ -184,9 +219,16 @@ func (c *client) Invoke(ctx context.Context, fqrn string, req, res interface{})
 	}
 	loggingRequest(req)
 	wakeUpClientConn(c.conn)
+	ctx = withResponseEncoding(ctx)
 	opts := []grpc.CallOption{grpc.Header(&header), grpc.Trailer(&trailer)}
+	if name := compressorFromContext(ctx); name != "" {
+		opts = append(opts, grpc.UseCompressor(name))
+	}
+	if p := peerFromContext(ctx); p != nil {
+		opts = append(opts, grpc.Peer(p))
+	}
 	err = c.conn.Invoke(ctx, endpoint, req, res, opts...)
-	return header, trailer, err
+	return withEncodingHeader(ctx, header), trailer, err
 }
 
 func (c *client) Close(ctx context.Context) error {
@@ -219,7 +261,8 @@ type clientStream struct {
 }
 
 func (s *clientStream) Header() (metadata.MD, error) {
-	return s.cs.Header()
+	header, err := s.cs.Header()
+	return withEncodingHeader(s.cs.Context(), header), err
 }
 
 func (s *clientStream) Trailer() metadata.MD {
@@ -249,10 +292,20 @@ func (c *client) NewClientStream(ctx context.Context, streamDesc *grpc.StreamDes
 		return nil, errors.Wrap(err, "failed to convert fqrn to endpoint")
 	}
 	wakeUpClientConn(c.conn)
-	cs, err := c.conn.NewStream(ctx, streamDesc, endpoint)
+	ctx = withResponseEncoding(ctx)
+	var opts []grpc.CallOption
+	if name := compressorFromContext(ctx); name != "" {
+		opts = append(opts, grpc.UseCompressor(name))
+	}
+	cs, err := c.conn.NewStream(ctx, streamDesc, endpoint, opts...)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to instantiate gRPC stream")
 	}
+	if p := peerFromContext(ctx); p != nil {
+		if sp, ok := peer.FromContext(cs.Context()); ok {
+			*p = *sp
+		}
+	}
 	return &clientStream{cs}, nil
 }
 
@@ -1,8 +1,32 @@
 package grpc
 
 import (
+	"context"
+	"net"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/ktr0731/evans/grpc/grpcreflection"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func Test_fqrnToEndpoint(t *testing.T) {
@@ -70,3 +94,321 @@ func TestNewClient(t *testing.T) {
 		})
 	}
 }
+
+func TestNewClient_UnixDomainSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix domain sockets are not supported")
+	}
+
+	sock := filepath.Join(t.TempDir(), "evans.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("failed to listen a Unix domain socket: %s", err)
+	}
+	srv := grpc.NewServer()
+	reflection.Register(srv)
+	go srv.Serve(l) //nolint:errcheck
+	defer srv.Stop()
+
+	client, err := NewClient("unix://"+sock, "", true, false, "", "", "", nil)
+	if err != nil {
+		t.Fatalf("NewClient must not return an error, but got '%s'", err)
+	}
+	defer client.Close(context.Background())
+
+	svcs, err := client.ListServices()
+	if err != nil {
+		t.Fatalf("ListServices must not return an error, but got '%s'", err)
+	}
+	if len(svcs) != 1 || svcs[0] != grpcreflection.ServiceNameV1Alpha {
+		t.Errorf("unexpected services: %v", svcs)
+	}
+}
+
+func TestNewClient_ReflectionV1(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen a TCP port: %s", err)
+	}
+	srv := grpc.NewServer()
+	// Register the reflection server as v1 only.
+	// The v1 API is the same as v1alpha except for the package name.
+	desc := rpb.ServerReflection_ServiceDesc
+	desc.ServiceName = grpcreflection.ServiceNameV1
+	srv.RegisterService(&desc, reflection.NewServer(reflection.ServerOptions{Services: srv}))
+	go srv.Serve(l) //nolint:errcheck
+	defer srv.Stop()
+
+	client, err := NewClient(l.Addr().String(), "", true, false, "", "", "", nil)
+	if err != nil {
+		t.Fatalf("NewClient must not return an error, but got '%s'", err)
+	}
+	defer client.Close(context.Background())
+
+	svcs, err := client.ListServices()
+	if err != nil {
+		t.Fatalf("ListServices must not return an error, but got '%s'", err)
+	}
+	if len(svcs) != 1 || svcs[0] != grpcreflection.ServiceNameV1 {
+		t.Errorf("unexpected services: %v", svcs)
+	}
+}
+
+func TestNewClient_Options(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen a TCP port: %s", err)
+	}
+	// The server returns a response larger than the default max receive message size (4MB).
+	var userAgent string
+	srv := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		md, _ := metadata.FromIncomingContext(stream.Context())
+		userAgent = strings.Join(md.Get("user-agent"), "")
+		if err := stream.RecvMsg(&wrapperspb.BytesValue{}); err != nil {
+			return err
+		}
+		return stream.SendMsg(&wrapperspb.BytesValue{Value: make([]byte, 5*1024*1024)})
+	}))
+	go srv.Serve(l) //nolint:errcheck
+	defer srv.Stop()
+
+	cases := map[string]struct {
+		opts []Option
+
+		expectedCode codes.Code
+	}{
+		"default":                  {expectedCode: codes.ResourceExhausted},
+		"larger max receive size":  {opts: []Option{WithMaxMsgSize(0, 8*1024*1024)}, expectedCode: codes.OK},
+		"with the other options":   {opts: []Option{WithMaxMsgSize(0, 8*1024*1024), WithKeepalive(time.Minute, time.Second), WithInitialWindowSize(1<<20, 1<<20)}, expectedCode: codes.OK},
+		"smaller max receive size": {opts: []Option{WithMaxMsgSize(0, 1024)}, expectedCode: codes.ResourceExhausted},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			opts := append([]Option{WithDialTimeout(time.Second), WithUserAgent("evans/test")}, c.opts...)
+			client, err := NewClient(l.Addr().String(), "", false, false, "", "", "", nil, opts...)
+			if err != nil {
+				t.Fatalf("NewClient must not return an error, but got '%s'", err)
+			}
+			defer client.Close(context.Background())
+
+			var res wrapperspb.BytesValue
+			_, _, err = client.Invoke(context.Background(), "api.Report.Get", &wrapperspb.BytesValue{}, &res)
+			if code := status.Code(errors.Cause(err)); code != c.expectedCode {
+				t.Fatalf("expected %s, but got '%v'", c.expectedCode, err)
+			}
+			if !strings.HasPrefix(userAgent, "evans/test ") {
+				t.Errorf("the user agent must start with 'evans/test', but got '%s'", userAgent)
+			}
+		})
+	}
+}
+
+func TestNewClient_LoadBalancing(t *testing.T) {
+	var addrs []string
+	for i := 0; i < 2; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen a TCP port: %s", err)
+		}
+		srv := grpc.NewServer()
+		healthpb.RegisterHealthServer(srv, health.NewServer())
+		go srv.Serve(l) //nolint:errcheck
+		defer srv.Stop()
+		addrs = append(addrs, l.Addr().String())
+	}
+
+	cases := map[string]struct {
+		policy string
+
+		expectedPeers []string
+	}{
+		"pick_first":  {policy: "pick_first", expectedPeers: addrs[:1]},
+		"round_robin": {policy: "round_robin", expectedPeers: addrs},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			client, err := NewClient(StaticTarget(addrs), "", false, false, "", "", "", nil, WithLoadBalancingPolicy(c.policy))
+			if err != nil {
+				t.Fatalf("NewClient must not return an error, but got '%s'", err)
+			}
+			defer client.Close(context.Background())
+
+			peers := map[string]bool{}
+			for i := 0; i < 10; i++ {
+				var p peer.Peer
+				ctx := NewContextWithPeer(context.Background(), &p)
+				var res healthpb.HealthCheckResponse
+				if _, _, err := client.Invoke(ctx, "grpc.health.v1.Health.Check", &healthpb.HealthCheckRequest{}, &res); err != nil {
+					t.Fatalf("Invoke must not return an error, but got '%s'", err)
+				}
+				peers[p.Addr.String()] = true
+			}
+			for _, addr := range c.expectedPeers {
+				if !peers[addr] {
+					t.Errorf("expected that '%s' served at least one call, but got %v", addr, peers)
+				}
+			}
+			if len(peers) != len(c.expectedPeers) {
+				t.Errorf("unexpected peers: %v", peers)
+			}
+		})
+	}
+}
+
+type staticCredentials map[string]string
+
+func (c staticCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return c, nil
+}
+
+func (c staticCredentials) RequireTransportSecurity() bool { return false }
+
+func TestNewClient_PerRPCCredentials(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen a TCP port: %s", err)
+	}
+	var unauthorized int32
+	authorize := func(ctx context.Context) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if strings.Join(md.Get("authorization"), "") != "Bearer kumiko" {
+			atomic.AddInt32(&unauthorized, 1)
+		}
+	}
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			authorize(ctx)
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			authorize(ss.Context())
+			return handler(srv, ss)
+		}),
+	)
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	reflection.Register(srv)
+	go srv.Serve(l) //nolint:errcheck
+	defer srv.Stop()
+
+	creds := staticCredentials{"authorization": "Bearer kumiko"}
+	client, err := NewClient(l.Addr().String(), "", true, false, "", "", "", nil, WithPerRPCCredentials(creds))
+	if err != nil {
+		t.Fatalf("NewClient must not return an error, but got '%s'", err)
+	}
+	defer client.Close(context.Background())
+
+	var res healthpb.HealthCheckResponse
+	if _, _, err := client.Invoke(context.Background(), "grpc.health.v1.Health.Check", &healthpb.HealthCheckRequest{}, &res); err != nil {
+		t.Fatalf("Invoke must not return an error, but got '%s'", err)
+	}
+	if _, err := client.ListServices(); err != nil {
+		t.Fatalf("ListServices must not return an error, but got '%s'", err)
+	}
+	if n := atomic.LoadInt32(&unauthorized); n != 0 {
+		t.Errorf("all requests must have the authorization header, but %d requests don't have it", n)
+	}
+}
+
+type serviceInfoProvider map[string]grpc.ServiceInfo
+
+func (p serviceInfoProvider) GetServiceInfo() map[string]grpc.ServiceInfo { return p }
+
+func TestNewClient_ReflectionTransitiveDependencies(t *testing.T) {
+	// shop/shop.proto imports common/money.proto. Both of them are not linked into the test binary.
+	files, err := protodesc.NewFiles(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:    proto.String("common/money.proto"),
+				Package: proto.String("common"),
+				Syntax:  proto.String("proto3"),
+				MessageType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("Money"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:     proto.String("units"),
+								JsonName: proto.String("units"),
+								Number:   proto.Int32(1),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_INT64.Enum(),
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:       proto.String("shop/shop.proto"),
+				Package:    proto.String("shop"),
+				Syntax:     proto.String("proto3"),
+				Dependency: []string{"common/money.proto"},
+				MessageType: []*descriptorpb.DescriptorProto{
+					{
+						Name: proto.String("Item"),
+						Field: []*descriptorpb.FieldDescriptorProto{
+							{
+								Name:     proto.String("price"),
+								JsonName: proto.String("price"),
+								Number:   proto.Int32(1),
+								Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+								Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+								TypeName: proto.String(".common.Money"),
+							},
+						},
+					},
+				},
+				Service: []*descriptorpb.ServiceDescriptorProto{
+					{
+						Name: proto.String("Shop"),
+						Method: []*descriptorpb.MethodDescriptorProto{
+							{
+								Name:       proto.String("GetItem"),
+								InputType:  proto.String(".shop.Item"),
+								OutputType: proto.String(".shop.Item"),
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to build file descriptors: %s", err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen a TCP port: %s", err)
+	}
+	srv := grpc.NewServer()
+	rpb.RegisterServerReflectionServer(srv, reflection.NewServer(reflection.ServerOptions{
+		Services:           serviceInfoProvider{"shop.Shop": {}},
+		DescriptorResolver: files,
+	}))
+	go srv.Serve(l) //nolint:errcheck
+	defer srv.Stop()
+
+	client, err := NewClient(l.Addr().String(), "", true, false, "", "", "", nil)
+	if err != nil {
+		t.Fatalf("NewClient must not return an error, but got '%s'", err)
+	}
+	defer client.Close(context.Background())
+
+	d, err := client.FindSymbol("shop.Shop")
+	if err != nil {
+		t.Fatalf("FindSymbol must not return an error, but got '%s'", err)
+	}
+	sd, ok := d.(protoreflect.ServiceDescriptor)
+	if !ok {
+		t.Fatalf("expected a service descriptor, but got %T", d)
+	}
+	price := sd.Methods().ByName("GetItem").Input().Fields().ByName("price")
+	if expected, actual := protoreflect.FullName("common.Money"), price.Message().FullName(); expected != actual {
+		t.Errorf("expected '%s', but got '%s'", expected, actual)
+	}
+
+	if _, err := protoregistry.GlobalFiles.FindFileByPath("common/money.proto"); err == nil {
+		t.Errorf("fetched files must not be registered to protoregistry.GlobalFiles")
+	}
+}
@@ -6,21 +6,27 @@ import (
 	"context"
 	"strings"
 
+	"github.com/jhump/protoreflect/desc"
 	gr "github.com/jhump/protoreflect/grpcreflect"
 	"github.com/ktr0731/grpc-web-go-client/grpcweb"
-	"github.com/ktr0731/grpc-web-go-client/grpcweb/grpcweb_reflection_v1alpha"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
-// ServiceName represents the gRPC reflection service name.
-const ServiceName = "grpc.reflection.v1alpha.ServerReflection"
+const (
+	// ServiceNameV1 represents the gRPC reflection v1 service name.
+	ServiceNameV1 = "grpc.reflection.v1.ServerReflection"
+	// ServiceNameV1Alpha represents the gRPC reflection v1alpha service name.
+	ServiceNameV1Alpha = "grpc.reflection.v1alpha.ServerReflection"
+)
+
+// ServiceNames represents all gRPC reflection service names the client supports.
+var ServiceNames = []string{ServiceNameV1, ServiceNameV1Alpha}
 
 var ErrTLSHandshakeFailed = errors.New("TLS handshake failed")
 
@@ -37,6 +43,8 @@ type Client interface {
 }
 
 type client struct {
+	// resolver holds files fetched from the server.
+	// It is separated from protoregistry.GlobalFiles to avoid conflicts with files linked into Evans.
 	resolver *protoregistry.Files
 	client   *gr.Client
 }
@@ -50,18 +58,20 @@ func getCtx(headers map[string][]string) context.Context {
 }
 
 // NewClient returns an instance of gRPC reflection client for gRPC protocol.
+// The client uses gRPC reflection v1 first. If the server doesn't support it, the client falls back to v1alpha.
 func NewClient(conn grpc.ClientConnInterface, headers map[string][]string) Client {
 	return &client{
-		client:   gr.NewClientV1Alpha(getCtx(headers), grpc_reflection_v1alpha.NewServerReflectionClient(conn)),
-		resolver: protoregistry.GlobalFiles,
+		client:   gr.NewClientAuto(getCtx(headers), conn),
+		resolver: &protoregistry.Files{},
 	}
 }
 
 // NewWebClient returns an instance of gRPC reflection client for gRPC-Web protocol.
+// Like NewClient, the client uses gRPC reflection v1 first, then falls back to v1alpha.
 func NewWebClient(conn *grpcweb.ClientConn, headers map[string][]string) Client {
 	return &client{
-		client:   gr.NewClientV1Alpha(getCtx(headers), grpcweb_reflection_v1alpha.NewServerReflectionClient(conn)),
-		resolver: protoregistry.GlobalFiles,
+		client:   gr.NewClientAuto(getCtx(headers), &webClientConn{conn: conn}),
+		resolver: &protoregistry.Files{},
 	}
 }
 
@@ -99,17 +109,36 @@ func (c *client) FindSymbol(name string) (protoreflect.Descriptor, error) {
 		return nil, errors.Wrap(err, "failed to find file containing symbol")
 	}
 
-	// TODO: consider dependencies
-	fd, err := protodesc.NewFile(jfd.AsFileDescriptorProto(), c.resolver)
-	if err != nil {
+	if err := c.registerFile(jfd); err != nil {
 		return nil, err
 	}
 
-	if err := c.resolver.RegisterFile(fd); err != nil {
-		return nil, err
+	return c.resolver.FindDescriptorByName(fullName)
+}
+
+// registerFile registers fd and its dependencies to c.resolver recursively.
+// Dependencies are already fetched via gRPC reflection by FileContainingSymbol.
+func (c *client) registerFile(fd *desc.FileDescriptor) error {
+	if _, err := c.resolver.FindFileByPath(fd.GetName()); err == nil {
+		return nil
 	}
 
-	return c.resolver.FindDescriptorByName(fullName)
+	for _, dep := range fd.GetDependencies() {
+		if err := c.registerFile(dep); err != nil {
+			return err
+		}
+	}
+
+	f, err := protodesc.NewFile(fd.AsFileDescriptorProto(), c.resolver)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create file descriptor of '%s'", fd.GetName())
+	}
+
+	if err := c.resolver.RegisterFile(f); err != nil {
+		return errors.Wrapf(err, "failed to register file '%s'", fd.GetName())
+	}
+
+	return nil
 }
 
 func (c *client) Reset() {
@@ -0,0 +1,101 @@
+package grpcreflection
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/ktr0731/grpc-web-go-client/grpcweb"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// webClientConn adapts *grpcweb.ClientConn to grpc.ClientConnInterface.
+// It allows the gRPC reflection client to choose the reflection version regardless of the protocol.
+type webClientConn struct {
+	conn *grpcweb.ClientConn
+}
+
+func (c *webClientConn) Invoke(ctx context.Context, method string, args, reply interface{}, _ ...grpc.CallOption) error {
+	return errors.Cause(c.conn.Invoke(ctx, method, args, reply))
+}
+
+func (c *webClientConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, _ ...grpc.CallOption) (grpc.ClientStream, error) {
+	if !desc.ClientStreams || !desc.ServerStreams {
+		return nil, errors.New("gRPC-Web reflection client supports only bidi streams")
+	}
+	stream, err := c.conn.NewBidiStream(desc, method)
+	if err != nil {
+		// Some gRPC-Web servers reject streams for unknown methods before establishing them.
+		// (e.g. improbable-eng/grpc-web responds 403 to the WebSocket handshake)
+		// Treat it as Unimplemented so that the reflection client can fall back to another version.
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	}
+	return &webClientStream{ctx: ctx, stream: stream}, nil
+}
+
+// webClientStream adapts grpcweb.BidiStream to grpc.ClientStream.
+type webClientStream struct {
+	ctx    context.Context
+	stream grpcweb.BidiStream
+}
+
+func (s *webClientStream) Header() (metadata.MD, error) {
+	return s.stream.Header()
+}
+
+func (s *webClientStream) Trailer() metadata.MD {
+	return s.stream.Trailer()
+}
+
+func (s *webClientStream) CloseSend() error {
+	return s.stream.CloseSend()
+}
+
+func (s *webClientStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *webClientStream) SendMsg(m interface{}) error {
+	return errors.Cause(s.stream.Send(s.ctx, m))
+}
+
+// RecvMsg receives a message. It unwraps errors because the reflection client checks
+// the status code (e.g. Unimplemented) of returned errors to choose the reflection version.
+func (s *webClientStream) RecvMsg(m interface{}) error {
+	err := errors.Cause(s.stream.Receive(s.ctx, m))
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	// grpcweb.BidiStream doesn't return the status of trailers-only responses.
+	// In that case, the status is contained in the response headers.
+	if stat := statusFromHeader(s.stream.Header()); stat != nil {
+		return stat.Err()
+	}
+	return err
+}
+
+// statusFromHeader returns a non-OK status contained in h, or nil if there is no such status.
+func statusFromHeader(h metadata.MD, err error) *status.Status {
+	if err != nil {
+		return nil
+	}
+	vals := h.Get("grpc-status")
+	if len(vals) == 0 {
+		return nil
+	}
+	code, err := strconv.Atoi(vals[0])
+	if err != nil || codes.Code(code) == codes.OK {
+		return nil
+	}
+	var msg string
+	if msgs := h.Get("grpc-message"); len(msgs) != 0 {
+		msg = msgs[0]
+	}
+	return status.New(codes.Code(code), msg)
+}
@@ -0,0 +1,205 @@
+package grpc
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+const defaultDialTimeout = 7 * time.Second
+
+type opt struct {
+	dialTimeout                              time.Duration
+	maxSendMsgSize, maxRecvMsgSize           int
+	keepaliveTime, keepaliveTimeout          time.Duration
+	initialWindowSize, initialConnWindowSize int32
+	userAgent                                string
+	lbPolicy                                 string
+	proxy                                    *url.URL
+	creds                                    credentials.PerRPCCredentials
+
+	insecureSkipVerify bool
+	pins               []string
+	tlsMinVersion      uint16
+	keyLogFile         string
+}
+
+// Option represents an option for NewClient, NewWebClient and NewConnectClient.
+type Option func(*opt)
+
+// WithDialTimeout modifies the timeout for establishing a connection. The default is 7 seconds.
+// If d is 0, there is no timeout.
+func WithDialTimeout(d time.Duration) Option {
+	return func(o *opt) {
+		o.dialTimeout = d
+	}
+}
+
+// WithMaxMsgSize modifies the max message size in bytes the client can send and receive.
+// The gRPC defaults are used for zero values. It is available for gRPC only.
+func WithMaxMsgSize(send, recv int) Option {
+	return func(o *opt) {
+		o.maxSendMsgSize = send
+		o.maxRecvMsgSize = recv
+	}
+}
+
+// WithKeepalive enables keepalive pings which are sent every d. The client waits for the ping ack for timeout.
+// If timeout is 0, the gRPC default is used. It is available for gRPC only.
+func WithKeepalive(d, timeout time.Duration) Option {
+	return func(o *opt) {
+		o.keepaliveTime = d
+		o.keepaliveTimeout = timeout
+	}
+}
+
+// WithInitialWindowSize modifies the initial window size of each stream and connection.
+// The gRPC defaults are used for zero values. It is available for gRPC only.
+func WithInitialWindowSize(stream, conn int32) Option {
+	return func(o *opt) {
+		o.initialWindowSize = stream
+		o.initialConnWindowSize = conn
+	}
+}
+
+// WithUserAgent modifies the user agent sent to the server.
+func WithUserAgent(ua string) Option {
+	return func(o *opt) {
+		o.userAgent = ua
+	}
+}
+
+// WithLoadBalancingPolicy modifies the load balancing policy such as "pick_first" or "round_robin".
+// It is meaningful for targets that are resolved to several addresses. It is available for gRPC only.
+func WithLoadBalancingPolicy(policy string) Option {
+	return func(o *opt) {
+		o.lbPolicy = policy
+	}
+}
+
+// WithProxy makes the client connect to servers through the proxy specified by u.
+// The scheme of u must be one of http, https and socks5. The user info of u is used for authentication.
+func WithProxy(u *url.URL) Option {
+	return func(o *opt) {
+		o.proxy = u
+	}
+}
+
+// WithPerRPCCredentials attaches the request metadata provided by creds such as an authorization header to each request.
+func WithPerRPCCredentials(creds credentials.PerRPCCredentials) Option {
+	return func(o *opt) {
+		o.creds = creds
+	}
+}
+
+// WithInsecureSkipVerify disables verification of the server certificate chain and host name.
+// It is meaningful only if TLS is enabled.
+func WithInsecureSkipVerify() Option {
+	return func(o *opt) {
+		o.insecureSkipVerify = true
+	}
+}
+
+// WithPinnedPublicKeys makes the client accept only servers whose certificate chain contains one of the public keys.
+// Each pin is the base64 encoded SHA-256 hash of the DER encoded SubjectPublicKeyInfo, optionally prefixed by "sha256/".
+// It is meaningful only if TLS is enabled.
+func WithPinnedPublicKeys(pins []string) Option {
+	return func(o *opt) {
+		o.pins = pins
+	}
+}
+
+// WithTLSMinVersion modifies the minimum TLS version such as tls.VersionTLS12.
+// It is meaningful only if TLS is enabled.
+func WithTLSMinVersion(v uint16) Option {
+	return func(o *opt) {
+		o.tlsMinVersion = v
+	}
+}
+
+// WithKeyLogFile makes the client append TLS session secrets to the file in NSS key log format.
+// Tools such as Wireshark can decrypt the traffic by the file. It is meaningful only if TLS is enabled.
+func WithKeyLogFile(name string) Option {
+	return func(o *opt) {
+		o.keyLogFile = name
+	}
+}
+
+func newOpt(opts []Option) *opt {
+	o := &opt{dialTimeout: defaultDialTimeout}
+	for _, f := range opts {
+		f(o)
+	}
+	return o
+}
+
+// dialOptions converts o to gRPC dial options.
+func (o *opt) dialOptions() []grpc.DialOption {
+	var opts []grpc.DialOption
+	if o.dialTimeout > 0 {
+		opts = append(opts, grpc.WithConnectParams(grpc.ConnectParams{
+			Backoff:           backoff.DefaultConfig,
+			MinConnectTimeout: o.dialTimeout,
+		}))
+	}
+
+	var callOpts []grpc.CallOption
+	if o.maxSendMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallSendMsgSize(o.maxSendMsgSize))
+	}
+	if o.maxRecvMsgSize > 0 {
+		callOpts = append(callOpts, grpc.MaxCallRecvMsgSize(o.maxRecvMsgSize))
+	}
+	if len(callOpts) != 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(callOpts...))
+	}
+
+	if o.keepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    o.keepaliveTime,
+			Timeout: o.keepaliveTimeout,
+		}))
+	}
+	if o.initialWindowSize > 0 {
+		opts = append(opts, grpc.WithInitialWindowSize(o.initialWindowSize))
+	}
+	if o.initialConnWindowSize > 0 {
+		opts = append(opts, grpc.WithInitialConnWindowSize(o.initialConnWindowSize))
+	}
+	if o.userAgent != "" {
+		opts = append(opts, grpc.WithUserAgent(o.userAgent))
+	}
+	if o.lbPolicy != "" {
+		opts = append(opts, grpc.WithDefaultServiceConfig(fmt.Sprintf(`{"loadBalancingConfig": [{%q: {}}]}`, o.lbPolicy)))
+	}
+	if o.creds != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(o.creds))
+	}
+	return opts
+}
+
+// dialer returns the function to establish connections to servers.
+// Connections go through the proxy if it is specified.
+func (o *opt) dialer() (dialFunc, error) {
+	d := &net.Dialer{Timeout: o.dialTimeout}
+	if o.proxy == nil {
+		return d.DialContext, nil
+	}
+	return newProxyDialer(o.proxy, d)
+}
+
+// httpProxy returns the proxy function for http.Transport. If the proxy is specified by WithProxy, it returns nil
+// because the connections returned by dialer already go through the proxy.
+func (o *opt) httpProxy() func(*http.Request) (*url.URL, error) {
+	if o.proxy != nil {
+		return nil
+	}
+	return http.ProxyFromEnvironment
+}
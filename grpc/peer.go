@@ -0,0 +1,20 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/peer"
+)
+
+type peerKey struct{}
+
+// NewContextWithPeer returns a new context that has p. The peer which serves the RPC called with the context is
+// stored to p. It is available for gRPC only, p is not modified for gRPC-Web and Connect protocol.
+func NewContextWithPeer(ctx context.Context, p *peer.Peer) context.Context {
+	return context.WithValue(ctx, peerKey{}, p)
+}
+
+func peerFromContext(ctx context.Context) *peer.Peer {
+	p, _ := ctx.Value(peerKey{}).(*peer.Peer)
+	return p
+}
@@ -0,0 +1,114 @@
+package grpc
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/proxy"
+)
+
+// dialFunc dials to addr on the named network.
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// newProxyDialer returns a dialFunc that establishes connections through the proxy specified by u.
+// The scheme of u must be one of http, https and socks5. The user info of u is used for authentication.
+// Connections to the proxy are established by d.
+func newProxyDialer(u *url.URL, d *net.Dialer) (dialFunc, error) {
+	switch u.Scheme {
+	case "http", "https":
+		return func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialHTTPProxy(ctx, u, d, network, addr)
+		}, nil
+	case "socks5":
+		var auth *proxy.Auth
+		if u.User != nil {
+			pass, _ := u.User.Password()
+			auth = &proxy.Auth{User: u.User.Username(), Password: pass}
+		}
+		dialer, err := proxy.SOCKS5("tcp", u.Host, auth, d)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to instantiate a SOCKS5 dialer")
+		}
+		return dialer.(proxy.ContextDialer).DialContext, nil
+	default:
+		return nil, errors.Errorf("unsupported proxy scheme '%s'", u.Scheme)
+	}
+}
+
+// dialHTTPProxy establishes a tunnel to addr by sending CONNECT request to the HTTP proxy specified by u.
+func dialHTTPProxy(ctx context.Context, u *url.URL, d *net.Dialer, network, addr string) (_ net.Conn, err error) {
+	conn, err := d.DialContext(ctx, network, u.Host)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dial to the proxy")
+	}
+	defer func() {
+		if err != nil {
+			conn.Close()
+		}
+	}()
+
+	if u.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to establish a TLS connection with the proxy")
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Host: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if u.User != nil {
+		pass, _ := u.User.Password()
+		cred := base64.StdEncoding.EncodeToString([]byte(u.User.Username() + ":" + pass))
+		req.Header.Set("Proxy-Authorization", "Basic "+cred)
+	}
+
+	// The handshake with the proxy must be finished by the deadline of ctx.
+	deadline, hasDeadline := ctx.Deadline()
+	if hasDeadline {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, errors.Wrap(err, "failed to set the deadline of the connection to the proxy")
+		}
+	}
+
+	if err := req.Write(conn); err != nil {
+		return nil, errors.Wrap(err, "failed to send CONNECT request to the proxy")
+	}
+	r := bufio.NewReader(conn)
+	res, err := http.ReadResponse(r, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the response from the proxy")
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("the proxy refused the connection: %s", res.Status)
+	}
+	if hasDeadline {
+		if err := conn.SetDeadline(time.Time{}); err != nil {
+			return nil, errors.Wrap(err, "failed to reset the deadline of the connection to the proxy")
+		}
+	}
+	return &bufferedConn{Conn: conn, r: r}, nil
+}
+
+// bufferedConn is a net.Conn that reads from r first.
+// r may hold data which has been sent by the server just after the tunnel was established.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
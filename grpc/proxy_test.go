@@ -0,0 +1,289 @@
+package grpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// httpProxy is an HTTP proxy which supports CONNECT method only.
+type httpProxy struct {
+	// auth is the expected Proxy-Authorization header. If it is empty, the proxy accepts all requests.
+	auth string
+	// tunnels is the number of established tunnels.
+	tunnels int32
+}
+
+func (p *httpProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if p.auth != "" && r.Header.Get("Proxy-Authorization") != p.auth {
+		w.WriteHeader(http.StatusProxyAuthRequired)
+		return
+	}
+	dst, err := net.Dial("tcp", r.Host)
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	src, _, err := w.(http.Hijacker).Hijack()
+	if err != nil {
+		dst.Close()
+		return
+	}
+	if _, err := io.WriteString(src, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+		src.Close()
+		dst.Close()
+		return
+	}
+	atomic.AddInt32(&p.tunnels, 1)
+	relay(src, dst)
+}
+
+// socks5Proxy is a SOCKS5 proxy which supports CONNECT command only.
+type socks5Proxy struct {
+	// user and password are expected credentials. If user is empty, the proxy doesn't require authentication.
+	user, password string
+	// tunnels is the number of established tunnels.
+	tunnels int32
+}
+
+func (p *socks5Proxy) serve(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go p.handle(conn)
+	}
+}
+
+func (p *socks5Proxy) handle(conn net.Conn) {
+	r := bufio.NewReader(conn)
+	// Version and methods.
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		conn.Close()
+		return
+	}
+	if _, err := io.ReadFull(r, make([]byte, hdr[1])); err != nil {
+		conn.Close()
+		return
+	}
+	if p.user == "" {
+		conn.Write([]byte{5, 0}) //nolint:errcheck
+	} else {
+		conn.Write([]byte{5, 2}) //nolint:errcheck
+		user, password, err := readSOCKS5Auth(r)
+		if err != nil || user != p.user || password != p.password {
+			conn.Write([]byte{1, 1}) //nolint:errcheck
+			conn.Close()
+			return
+		}
+		conn.Write([]byte{1, 0}) //nolint:errcheck
+	}
+
+	// Request. The address type must be IPv4 or a domain name.
+	var req [4]byte
+	if _, err := io.ReadFull(r, req[:]); err != nil {
+		conn.Close()
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		b := make([]byte, 4)
+		if _, err := io.ReadFull(r, b); err != nil {
+			conn.Close()
+			return
+		}
+		host = net.IP(b).String()
+	case 3:
+		n, err := r.ReadByte()
+		if err != nil {
+			conn.Close()
+			return
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			conn.Close()
+			return
+		}
+		host = string(b)
+	default:
+		conn.Close()
+		return
+	}
+	var port [2]byte
+	if _, err := io.ReadFull(r, port[:]); err != nil {
+		conn.Close()
+		return
+	}
+	dst, err := net.Dial("tcp", net.JoinHostPort(host, fmt.Sprint(binary.BigEndian.Uint16(port[:]))))
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0}) //nolint:errcheck
+		conn.Close()
+		return
+	}
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}) //nolint:errcheck
+	atomic.AddInt32(&p.tunnels, 1)
+	relay(conn, dst)
+}
+
+func readSOCKS5Auth(r *bufio.Reader) (string, string, error) {
+	readString := func() (string, error) {
+		n, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		b := make([]byte, n)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+	if _, err := r.ReadByte(); err != nil {
+		return "", "", err
+	}
+	user, err := readString()
+	if err != nil {
+		return "", "", err
+	}
+	password, err := readString()
+	if err != nil {
+		return "", "", err
+	}
+	return user, password, nil
+}
+
+func relay(a, b net.Conn) {
+	go func() {
+		io.Copy(a, b) //nolint:errcheck
+		a.Close()
+	}()
+	io.Copy(b, a) //nolint:errcheck
+	b.Close()
+}
+
+func TestNewClient_Proxy(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen a TCP port: %s", err)
+	}
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	reflection.Register(srv)
+	go srv.Serve(l) //nolint:errcheck
+	defer srv.Stop()
+
+	listen := func(t *testing.T) net.Listener {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("failed to listen a TCP port: %s", err)
+		}
+		t.Cleanup(func() { l.Close() })
+		return l
+	}
+
+	cases := map[string]struct {
+		// startProxy starts a proxy and returns the proxy URL without user info and the number of tunnels.
+		startProxy func(t *testing.T) (string, *int32)
+		user       *url.Userinfo
+
+		hasErr bool
+	}{
+		"http": {
+			startProxy: func(t *testing.T) (string, *int32) {
+				p := &httpProxy{}
+				l := listen(t)
+				go http.Serve(l, p) //nolint:errcheck
+				return "http://" + l.Addr().String(), &p.tunnels
+			},
+		},
+		"http with credentials": {
+			startProxy: func(t *testing.T) (string, *int32) {
+				p := &httpProxy{auth: "Basic a3VtaWtvOm91bWFl"} // kumiko:oumae
+				l := listen(t)
+				go http.Serve(l, p) //nolint:errcheck
+				return "http://" + l.Addr().String(), &p.tunnels
+			},
+			user: url.UserPassword("kumiko", "oumae"),
+		},
+		"http with wrong credentials": {
+			startProxy: func(t *testing.T) (string, *int32) {
+				p := &httpProxy{auth: "Basic a3VtaWtvOm91bWFl"}
+				l := listen(t)
+				go http.Serve(l, p) //nolint:errcheck
+				return "http://" + l.Addr().String(), &p.tunnels
+			},
+			user:   url.UserPassword("kumiko", "kousaka"),
+			hasErr: true,
+		},
+		"socks5": {
+			startProxy: func(t *testing.T) (string, *int32) {
+				p := &socks5Proxy{}
+				l := listen(t)
+				go p.serve(l)
+				return "socks5://" + l.Addr().String(), &p.tunnels
+			},
+		},
+		"socks5 with credentials": {
+			startProxy: func(t *testing.T) (string, *int32) {
+				p := &socks5Proxy{user: "kumiko", password: "oumae"}
+				l := listen(t)
+				go p.serve(l)
+				return "socks5://" + l.Addr().String(), &p.tunnels
+			},
+			user: url.UserPassword("kumiko", "oumae"),
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			rawURL, tunnels := c.startProxy(t)
+			u, err := url.Parse(rawURL)
+			if err != nil {
+				t.Fatalf("failed to parse the proxy URL: %s", err)
+			}
+			u.User = c.user
+
+			client, err := NewClient(l.Addr().String(), "", true, false, "", "", "", nil, WithProxy(u))
+			if err != nil {
+				t.Fatalf("NewClient must not return an error, but got '%s'", err)
+			}
+			defer client.Close(context.Background())
+
+			var res healthpb.HealthCheckResponse
+			_, _, err = client.Invoke(context.Background(), "grpc.health.v1.Health.Check", &healthpb.HealthCheckRequest{}, &res)
+			if c.hasErr {
+				if err == nil {
+					t.Errorf("Invoke must return an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Invoke must not return an error, but got '%s'", err)
+			}
+			if _, err := client.ListServices(); err != nil {
+				t.Fatalf("ListServices must not return an error, but got '%s'", err)
+			}
+			if n := atomic.LoadInt32(tunnels); n == 0 {
+				t.Errorf("the connection must go through the proxy")
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package grpc
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// staticScheme is the scheme of targets that consist of several addresses.
+const staticScheme = "static"
+
+func init() {
+	resolver.Register(&staticResolverBuilder{})
+}
+
+// StaticTarget returns a dial target which is resolved to addrs. Each address must be formed "host:port".
+// Calls using the target are distributed to addrs according to the load balancing policy.
+func StaticTarget(addrs []string) string {
+	return staticScheme + ":///" + strings.Join(addrs, ",")
+}
+
+// staticResolverBuilder builds resolvers for targets such as "static:///host1:50051,host2:50051".
+type staticResolverBuilder struct{}
+
+func (b *staticResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	var addrs []resolver.Address
+	for _, addr := range strings.Split(strings.TrimPrefix(target.URL.Path, "/"), ",") {
+		if addr == "" {
+			continue
+		}
+		addrs = append(addrs, resolver.Address{Addr: addr})
+	}
+	if err := cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		return nil, err
+	}
+	return staticResolver{}, nil
+}
+
+func (b *staticResolverBuilder) Scheme() string {
+	return staticScheme
+}
+
+// staticResolver does nothing because addresses never change.
+type staticResolver struct{}
+
+func (staticResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (staticResolver) Close() {}
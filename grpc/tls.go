@@ -0,0 +1,72 @@
+package grpc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// configureTLS applies the TLS related options to cfg.
+func (o *opt) configureTLS(cfg *tls.Config) error {
+	cfg.InsecureSkipVerify = o.insecureSkipVerify
+	if o.tlsMinVersion != 0 {
+		cfg.MinVersion = o.tlsMinVersion
+	}
+	if o.keyLogFile != "" {
+		cfg.KeyLogWriter = keyLogFile(o.keyLogFile)
+	}
+	if len(o.pins) != 0 {
+		pins, err := decodePins(o.pins)
+		if err != nil {
+			return err
+		}
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			return verifyPins(cs, pins)
+		}
+	}
+	return nil
+}
+
+// decodePins decodes base64 encoded SHA-256 hashes.
+func decodePins(pins []string) ([][]byte, error) {
+	decoded := make([][]byte, 0, len(pins))
+	for _, pin := range pins {
+		b, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, "sha256/"))
+		if err != nil || len(b) != sha256.Size {
+			return nil, errors.Errorf("invalid public key pin '%s', it must be a base64 encoded SHA-256 hash", pin)
+		}
+		decoded = append(decoded, b)
+	}
+	return decoded, nil
+}
+
+// verifyPins returns an error if no certificates in the peer certificate chain have one of the pinned public keys.
+func verifyPins(cs tls.ConnectionState, pins [][]byte) error {
+	for _, cert := range cs.PeerCertificates {
+		h := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+		for _, pin := range pins {
+			if bytes.Equal(h[:], pin) {
+				return nil
+			}
+		}
+	}
+	return errors.New("no certificates in the server certificate chain match the pinned public keys")
+}
+
+// keyLogFile is an io.Writer which appends TLS session secrets to the named file.
+// The file is opened for each write because it is not closed explicitly.
+type keyLogFile string
+
+func (f keyLogFile) Write(b []byte) (int, error) {
+	file, err := os.OpenFile(string(f), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to open the key log file")
+	}
+	defer file.Close()
+	return file.Write(b)
+}
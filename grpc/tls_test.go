@@ -0,0 +1,117 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+func TestNewClient_TLSOptions(t *testing.T) {
+	certPath := func(s string) string {
+		return filepath.Join("testdata", "cert", s)
+	}
+	serverCert, err := tls.LoadX509KeyPair(certPath("localhost.pem"), certPath("localhost-key.pem"))
+	if err != nil {
+		t.Fatalf("failed to load the server certificate: %s", err)
+	}
+	b, err := os.ReadFile(certPath("localhost.pem"))
+	if err != nil {
+		t.Fatalf("failed to read the server certificate: %s", err)
+	}
+	block, _ := pem.Decode(b)
+	leaf, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse the server certificate: %s", err)
+	}
+	h := sha256.Sum256(leaf.RawSubjectPublicKeyInfo)
+	pin := base64.StdEncoding.EncodeToString(h[:])
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen a TCP port: %s", err)
+	}
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		MaxVersion:   tls.VersionTLS12,
+	})))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(l) //nolint:errcheck
+	defer srv.Stop()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	addr := net.JoinHostPort("localhost", port)
+
+	keyLog := filepath.Join(t.TempDir(), "keylog")
+
+	cases := map[string]struct {
+		cacert string
+		opts   []Option
+
+		hasErr bool
+	}{
+		"trusted CA":                        {cacert: certPath("rootCA.pem")},
+		"untrusted CA":                      {hasErr: true},
+		"untrusted CA with skip verify":     {opts: []Option{WithInsecureSkipVerify()}},
+		"pinned public key":                 {cacert: certPath("rootCA.pem"), opts: []Option{WithPinnedPublicKeys([]string{"sha256/" + pin})}},
+		"pinned public key with skip":       {opts: []Option{WithInsecureSkipVerify(), WithPinnedPublicKeys([]string{pin})}},
+		"unmatched public key pin":          {cacert: certPath("rootCA.pem"), opts: []Option{WithPinnedPublicKeys([]string{base64.StdEncoding.EncodeToString(make([]byte, 32))})}, hasErr: true},
+		"TLS 1.2 is allowed":                {cacert: certPath("rootCA.pem"), opts: []Option{WithTLSMinVersion(tls.VersionTLS12)}},
+		"TLS 1.3 is not supported":          {cacert: certPath("rootCA.pem"), opts: []Option{WithTLSMinVersion(tls.VersionTLS13)}, hasErr: true},
+		"write session secrets to key file": {cacert: certPath("rootCA.pem"), opts: []Option{WithKeyLogFile(keyLog)}},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			client, err := NewClient(addr, "", false, true, c.cacert, "", "", nil, c.opts...)
+			if err != nil {
+				t.Fatalf("NewClient must not return an error, but got '%s'", err)
+			}
+			defer client.Close(context.Background())
+
+			var p peer.Peer
+			ctx := NewContextWithPeer(context.Background(), &p)
+			var res healthpb.HealthCheckResponse
+			_, _, err = client.Invoke(ctx, "grpc.health.v1.Health.Check", &healthpb.HealthCheckRequest{}, &res)
+			if c.hasErr {
+				if err == nil {
+					t.Errorf("Invoke must return an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Invoke must not return an error, but got '%s'", err)
+			}
+			info, ok := p.AuthInfo.(credentials.TLSInfo)
+			if !ok {
+				t.Fatalf("expected credentials.TLSInfo, but got %T", p.AuthInfo)
+			}
+			if info.State.Version != tls.VersionTLS12 {
+				t.Errorf("expected TLS 1.2, but got %x", info.State.Version)
+			}
+		})
+	}
+
+	if _, err := NewClient(addr, "", false, true, "", "", "", nil, WithPinnedPublicKeys([]string{"invalid"})); err == nil {
+		t.Errorf("NewClient must return an error if the pin is invalid")
+	}
+
+	b, err = os.ReadFile(keyLog)
+	if err != nil {
+		t.Fatalf("the key log file must be written, but got '%s'", err)
+	}
+	if len(b) == 0 {
+		t.Errorf("the key log file must not be empty")
+	}
+}
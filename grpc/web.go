@@ -9,33 +9,65 @@ import (
 	"github.com/pkg/errors"
 	gogrpc "google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 type webClient struct {
 	conn    *grpcweb.ClientConn
 	headers Headers
 
+	// unregister unregisters the transport config of conn and closes its idle connections.
+	unregister func()
+
 	grpcreflection.Client
 }
 
-func NewWebClient(addr string, useReflection, useTLS bool, cacert, cert, certKey string, headers Headers) Client {
-	conn, err := grpcweb.DialContext(addr)
+// NewWebClient creates a new gRPC-Web client. Unlike NewClient, addr must be formed "host:port".
+// The rest of arguments are the same as NewClient's.
+func NewWebClient(addr, serverName string, useReflection, useTLS bool, cacert, cert, certKey string, headers Headers, opts ...Option) (Client, error) {
+	o := newOpt(opts)
+	cfg := webTransportConfig{addr: addr, dialTimeout: o.dialTimeout, userAgent: o.userAgent, creds: o.creds}
+	if useTLS {
+		tlsCfg, err := newTLSConfig(serverName, cacert, cert, certKey)
+		if err != nil {
+			return nil, err
+		}
+		if err := o.configureTLS(tlsCfg); err != nil {
+			return nil, err
+		}
+		cfg.tlsCfg = tlsCfg
+	}
+	if o.proxy != nil {
+		dial, err := o.dialer()
+		if err != nil {
+			return nil, err
+		}
+		cfg.dial = dial
+	}
+
+	key, unregister := registerWebTransportConfig(&cfg)
+	conn, err := grpcweb.DialContext(key)
 	if err != nil {
-		panic(err)
+		unregister()
+		return nil, errors.Wrap(err, "failed to dial to gRPC-Web server")
 	}
 	client := &webClient{
-		conn:    conn,
-		headers: Headers{},
+		conn:       conn,
+		headers:    Headers{},
+		unregister: unregister,
 	}
 
 	if useReflection {
 		client.Client = grpcreflection.NewWebClient(conn, headers)
 	}
 
-	return client
+	return client, nil
 }
 
 func (c *webClient) Invoke(ctx context.Context, fqrn string, req, res interface{}) (header, trailer metadata.MD, _ error) {
+	if compressorFromContext(ctx) != "" {
+		return nil, nil, ErrCompressionNotSupported
+	}
 	endpoint, err := fqrnToEndpoint(fqrn)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "grpc-web: failed to convert FQRN to endpoint")
@@ -77,6 +109,9 @@ func (s *webClientStream) CloseAndReceive(res interface{}) error {
 }
 
 func (c *webClient) NewClientStream(ctx context.Context, streamDesc *gogrpc.StreamDesc, fqrn string) (ClientStream, error) {
+	if compressorFromContext(ctx) != "" {
+		return nil, ErrCompressionNotSupported
+	}
 	endpoint, err := fqrnToEndpoint(fqrn)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to convert FQRN to endpoint")
@@ -125,6 +160,9 @@ func (s *webServerStream) Receive(res interface{}) error {
 }
 
 func (c *webClient) NewServerStream(ctx context.Context, streamDesc *gogrpc.StreamDesc, fqrn string) (ServerStream, error) {
+	if compressorFromContext(ctx) != "" {
+		return nil, ErrCompressionNotSupported
+	}
 	endpoint, err := fqrnToEndpoint(fqrn)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to convert FQRN to endpoint")
@@ -141,6 +179,9 @@ func (c *webClient) NewServerStream(ctx context.Context, streamDesc *gogrpc.Stre
 type webBidiStream struct {
 	ctx    context.Context
 	stream grpcweb.BidiStream
+
+	// finished reports whether the server finished the stream.
+	finished bool
 }
 
 func (s *webBidiStream) Header() (metadata.MD, error) {
@@ -148,6 +189,11 @@ func (s *webBidiStream) Header() (metadata.MD, error) {
 }
 
 func (s *webBidiStream) Trailer() metadata.MD {
+	// grpcweb.BidiStream.Trailer panics if the stream is not finished by the server.
+	// e.g. the stream is aborted because of the deadline.
+	if !s.finished {
+		return nil
+	}
 	return s.stream.Trailer()
 }
 
@@ -162,8 +208,13 @@ func (s *webBidiStream) Send(req interface{}) error {
 func (s *webBidiStream) Receive(res interface{}) error {
 	err := s.stream.Receive(s.ctx, res)
 	if errors.Is(err, io.EOF) {
+		s.finished = true
 		return io.EOF
 	}
+	if _, ok := status.FromError(err); ok && err != nil {
+		s.finished = true
+		return err
+	}
 	if err != nil {
 		return errors.Wrap(err, "failed to receive a response")
 	}
@@ -178,6 +229,9 @@ func (s *webBidiStream) CloseSend() error {
 }
 
 func (c *webClient) NewBidiStream(ctx context.Context, streamDesc *gogrpc.StreamDesc, fqrn string) (BidiStream, error) {
+	if compressorFromContext(ctx) != "" {
+		return nil, ErrCompressionNotSupported
+	}
 	endpoint, err := fqrnToEndpoint(fqrn)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to convert FQRN to endpoint")
@@ -198,6 +252,7 @@ func (c *webClient) Close(ctx context.Context) error {
 	if c.Client != nil {
 		c.Client.Reset()
 	}
+	c.unregister()
 	return nil
 }
 
@@ -2,13 +2,27 @@ package grpc_test
 
 import (
 	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"testing"
 
+	"github.com/improbable-eng/grpc-web/go/grpcweb"
 	"github.com/ktr0731/evans/grpc"
+	"github.com/ktr0731/evans/grpc/grpcreflection"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
 )
 
 func TestWebClient(t *testing.T) {
-	client := grpc.NewWebClient("", false, false, "", "", "", nil)
+	client, err := grpc.NewWebClient("", "", false, false, "", "", "", nil)
+	if err != nil {
+		t.Fatalf("NewWebClient must not return an error, but got '%s'", err)
+	}
 	t.Run("Invoke returns an error if FQRN is invalid", func(t *testing.T) {
 		_, _, err := client.Invoke(context.Background(), "invalid-fqrn", nil, nil)
 		if err == nil {
@@ -34,3 +48,142 @@ func TestWebClient(t *testing.T) {
 		}
 	})
 }
+
+func TestWebClient_TLS(t *testing.T) {
+	//nolint:gocritic
+	certPath := func(s ...string) string {
+		return filepath.Join(append([]string{"testdata", "cert"}, s...)...)
+	}
+
+	cases := map[string]struct {
+		requireClientCert bool
+		cert, certKey     string
+
+		hasErr bool
+	}{
+		"server TLS":                            {},
+		"mutual TLS":                            {requireClientCert: true, cert: certPath("localhost.pem"), certKey: certPath("localhost-key.pem")},
+		"mutual TLS without client certificate": {requireClientCert: true, hasErr: true},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			addr := startWebServer(t, c.requireClientCert, func(s *gogrpc.Server) { reflection.Register(s) })
+
+			client, err := grpc.NewWebClient(addr, "localhost", true, true, certPath("rootCA.pem"), c.cert, c.certKey, nil)
+			if err != nil {
+				t.Fatalf("NewWebClient must not return an error, but got '%s'", err)
+			}
+			defer client.Close(context.Background())
+
+			svcs, err := client.ListServices()
+			if c.hasErr {
+				if err == nil {
+					t.Fatalf("ListServices must return an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ListServices must not return an error, but got '%s'", err)
+			}
+			if len(svcs) != 2 {
+				t.Errorf("expected two services, but got %v", svcs)
+			}
+
+			var res healthpb.HealthCheckResponse
+			if _, _, err := client.Invoke(context.Background(), "grpc.health.v1.Health.Check", &healthpb.HealthCheckRequest{}, &res); err != nil {
+				t.Fatalf("Invoke must not return an error, but got '%s'", err)
+			}
+			if res.Status != healthpb.HealthCheckResponse_SERVING {
+				t.Errorf("expected SERVING, but got %s", res.Status)
+			}
+		})
+	}
+}
+
+func TestWebClient_SameAddress(t *testing.T) {
+	addr := startWebServer(t, false, func(s *gogrpc.Server) { reflection.Register(s) })
+	rootCA := filepath.Join("testdata", "cert", "rootCA.pem")
+
+	// Clients connecting to the same address must not share their settings.
+	client, err := grpc.NewWebClient(addr, "localhost", false, true, rootCA, "", "", nil)
+	if err != nil {
+		t.Fatalf("NewWebClient must not return an error, but got '%s'", err)
+	}
+	defer client.Close(context.Background())
+	invalid, err := grpc.NewWebClient(addr, "example.com", false, true, rootCA, "", "", nil)
+	if err != nil {
+		t.Fatalf("NewWebClient must not return an error, but got '%s'", err)
+	}
+	defer invalid.Close(context.Background())
+
+	for i := 0; i < 2; i++ {
+		var res healthpb.HealthCheckResponse
+		if _, _, err := client.Invoke(context.Background(), "grpc.health.v1.Health.Check", &healthpb.HealthCheckRequest{}, &res); err != nil {
+			t.Fatalf("Invoke must not return an error, but got '%s'", err)
+		}
+	}
+	var res healthpb.HealthCheckResponse
+	if _, _, err := invalid.Invoke(context.Background(), "grpc.health.v1.Health.Check", &healthpb.HealthCheckRequest{}, &res); err == nil {
+		t.Error("Invoke must return an error because the server name doesn't match the certificate, but got nil")
+	}
+}
+
+func TestWebClient_ReflectionV1(t *testing.T) {
+	// Register the reflection server as v1 only.
+	// The v1 API is the same as v1alpha except for the package name.
+	registerV1 := func(s *gogrpc.Server) {
+		desc := rpb.ServerReflection_ServiceDesc
+		desc.ServiceName = grpcreflection.ServiceNameV1
+		s.RegisterService(&desc, reflection.NewServer(reflection.ServerOptions{Services: s}))
+	}
+	addr := startWebServer(t, false, registerV1)
+
+	client, err := grpc.NewWebClient(addr, "localhost", true, true, filepath.Join("testdata", "cert", "rootCA.pem"), "", "", nil)
+	if err != nil {
+		t.Fatalf("NewWebClient must not return an error, but got '%s'", err)
+	}
+	defer client.Close(context.Background())
+
+	svcs, err := client.ListServices()
+	if err != nil {
+		t.Fatalf("ListServices must not return an error, but got '%s'", err)
+	}
+	var found bool
+	for _, svc := range svcs {
+		if svc == grpcreflection.ServiceNameV1 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected %s in services, but got %v", grpcreflection.ServiceNameV1, svcs)
+	}
+}
+
+// startWebServer starts a gRPC-Web server over TLS, and returns its address.
+// registerReflection is called to register the gRPC reflection service.
+func startWebServer(t *testing.T, requireClientCert bool, registerReflection func(*gogrpc.Server)) string {
+	t.Helper()
+
+	cert, err := tls.LoadX509KeyPair(filepath.Join("testdata", "cert", "localhost.pem"), filepath.Join("testdata", "cert", "localhost-key.pem"))
+	if err != nil {
+		t.Fatalf("failed to load the server certificate: %s", err)
+	}
+
+	s := gogrpc.NewServer()
+	registerReflection(s)
+	healthpb.RegisterHealthServer(s, health.NewServer())
+	ws := grpcweb.WrapServer(s,
+		grpcweb.WithWebsockets(true),
+		grpcweb.WithWebsocketOriginFunc(func(*http.Request) bool { return true }),
+	)
+	srv := httptest.NewUnstartedServer(ws)
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	if requireClientCert {
+		srv.TLS.ClientAuth = tls.RequireAnyClientCert
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	return srv.Listener.Addr().String()
+}
@@ -0,0 +1,421 @@
+package grpc
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/ktr0731/grpc-web-go-client/grpcweb/transport"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+)
+
+// ktr0731/grpc-web-go-client always uses plain HTTP and WebSocket connections, and it has no options to change them.
+// However, its transports are replaceable, so we replace them with our own implementations.
+// The client passes only the address given to grpcweb.DialContext to the transports, so each web client dials with
+// the key of its connection settings instead of the address. See registerWebTransportConfig.
+// Transports for other addresses are created by the original implementations.
+var (
+	newOriginalUnaryTransport        = transport.NewUnary
+	newOriginalClientStreamTransport = transport.NewClientStream
+)
+
+func init() {
+	transport.NewUnary = newWebUnaryTransport
+	transport.NewClientStream = newWebClientStreamTransport
+}
+
+// webTransportConfig represents connection settings for a gRPC-Web server.
+type webTransportConfig struct {
+	// addr is the address of the server formed "host:port".
+	addr string
+	// tlsCfg is nil if the connection is not secure.
+	tlsCfg *tls.Config
+	// dialTimeout is the timeout for establishing a connection. 0 means no timeout.
+	dialTimeout time.Duration
+	// userAgent is sent as User-Agent header if it is not empty.
+	userAgent string
+	// dial establishes connections through the proxy. It is nil if the proxy is not specified.
+	dial dialFunc
+	// creds provides request metadata attached to each request. It may be nil.
+	creds credentials.PerRPCCredentials
+
+	// client is shared by all unary calls to reuse connections. It is set by registerWebTransportConfig.
+	client *http.Client
+}
+
+func (c *webTransportConfig) httpScheme() string {
+	if c.tlsCfg != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func (c *webTransportConfig) webSocketScheme() string {
+	if c.tlsCfg != nil {
+		return "wss"
+	}
+	return "ws"
+}
+
+// maxIdleWebConns is the maximum number of idle connections kept by a web client. It is larger than the default of
+// net/http because concurrent calls such as bench and batch share the connections to the same server.
+const maxIdleWebConns = 100
+
+func (c *webTransportConfig) newHTTPClient() *http.Client {
+	tr := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         (&net.Dialer{Timeout: c.dialTimeout}).DialContext,
+		TLSClientConfig:     c.tlsCfg,
+		MaxIdleConnsPerHost: maxIdleWebConns,
+	}
+	if c.dial != nil {
+		tr.Proxy = nil
+		tr.DialContext = c.dial
+	}
+	return &http.Client{Transport: tr}
+}
+
+func (c *webTransportConfig) webSocketDialer() *websocket.Dialer {
+	if c.tlsCfg == nil && c.dialTimeout == 0 && c.dial == nil {
+		return websocket.DefaultDialer
+	}
+	d := *websocket.DefaultDialer
+	d.TLSClientConfig = c.tlsCfg
+	if c.dial != nil {
+		d.Proxy = nil
+		d.NetDialContext = c.dial
+	}
+	if c.dialTimeout > 0 {
+		d.HandshakeTimeout = c.dialTimeout
+	}
+	return &d
+}
+
+// setPerRPCCredentials sets the request metadata provided by creds to h. It does nothing if creds is nil.
+// Like gRPC, it returns an error if creds requires transport security but uri is not secure.
+func setPerRPCCredentials(ctx context.Context, creds credentials.PerRPCCredentials, h http.Header, uri string) error {
+	if creds == nil {
+		return nil
+	}
+	if creds.RequireTransportSecurity() {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse the URI '%s'", uri)
+		}
+		if u.Scheme != "https" && u.Scheme != "wss" {
+			return errors.New("the credentials require transport level security, but the connection is not secure")
+		}
+	}
+	md, err := creds.GetRequestMetadata(ctx, uri)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the request metadata from the credentials")
+	}
+	for k, v := range md {
+		h.Set(k, v)
+	}
+	return nil
+}
+
+var webTransportConfigs = struct {
+	sync.RWMutex
+	m map[string]*webTransportConfig
+	// n is the number of registered configs. It is used to generate keys.
+	n int
+}{m: map[string]*webTransportConfig{}}
+
+// registerWebTransportConfig registers cfg and returns its key. The key is passed to grpcweb.DialContext instead of
+// the address, so clients connecting to the same address with different settings don't conflict.
+// The returned function unregisters cfg and closes its idle connections.
+func registerWebTransportConfig(cfg *webTransportConfig) (string, func()) {
+	webTransportConfigs.Lock()
+	defer webTransportConfigs.Unlock()
+	cfg.client = cfg.newHTTPClient()
+	webTransportConfigs.n++
+	key := fmt.Sprintf("evans-web-transport-%d", webTransportConfigs.n)
+	webTransportConfigs.m[key] = cfg
+	return key, func() {
+		webTransportConfigs.Lock()
+		defer webTransportConfigs.Unlock()
+		delete(webTransportConfigs.m, key)
+		cfg.client.CloseIdleConnections()
+	}
+}
+
+func lookupWebTransportConfig(key string) (*webTransportConfig, bool) {
+	webTransportConfigs.RLock()
+	defer webTransportConfigs.RUnlock()
+	cfg, ok := webTransportConfigs.m[key]
+	return cfg, ok
+}
+
+// webUnaryTransport is the same as the original implementation, but it supports TLS.
+type webUnaryTransport struct {
+	host      string
+	scheme    string
+	client    *http.Client
+	userAgent string
+	creds     credentials.PerRPCCredentials
+
+	header http.Header
+
+	sent bool
+}
+
+func newWebUnaryTransport(key string, opts *transport.ConnectOptions) transport.UnaryTransport {
+	cfg, ok := lookupWebTransportConfig(key)
+	if !ok {
+		return newOriginalUnaryTransport(key, opts)
+	}
+	return &webUnaryTransport{
+		host:      cfg.addr,
+		scheme:    cfg.httpScheme(),
+		client:    cfg.client,
+		userAgent: cfg.userAgent,
+		creds:     cfg.creds,
+		header:    make(http.Header),
+	}
+}
+
+func (t *webUnaryTransport) Header() http.Header {
+	return t.header
+}
+
+func (t *webUnaryTransport) Send(ctx context.Context, endpoint, contentType string, body io.Reader) (http.Header, io.ReadCloser, error) {
+	if t.sent {
+		return nil, nil, errors.New("Send must be called only one time per one Request")
+	}
+	defer func() {
+		t.sent = true
+	}()
+
+	u := url.URL{Scheme: t.scheme, Host: t.host, Path: endpoint}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to build the API request")
+	}
+
+	req.Header = t.Header()
+	req.Header.Add("content-type", contentType)
+	req.Header.Add("x-grpc-web", "1")
+	if t.userAgent != "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	if err := setPerRPCCredentials(ctx, t.creds, req.Header, u.String()); err != nil {
+		return nil, nil, err
+	}
+
+	res, err := t.client.Do(req)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to send the API")
+	}
+
+	return res.Header, res.Body, nil
+}
+
+// Close does nothing because the HTTP client is shared by calls of the web client.
+func (t *webUnaryTransport) Close() error {
+	return nil
+}
+
+// webSocketTransport is the same as the original implementation, but it supports TLS.
+// See transport.ClientStreamTransport for details.
+type webSocketTransport struct {
+	conn *websocket.Conn
+
+	once    sync.Once
+	resOnce sync.Once
+
+	closed bool
+
+	writeMu sync.Mutex
+
+	reqHeader, header, trailer http.Header
+}
+
+func newWebClientStreamTransport(key, endpoint string) (transport.ClientStreamTransport, error) {
+	cfg, ok := lookupWebTransportConfig(key)
+	if !ok {
+		return newOriginalClientStreamTransport(key, endpoint)
+	}
+	u := url.URL{Scheme: cfg.webSocketScheme(), Host: cfg.addr, Path: endpoint}
+	h := http.Header{}
+	h.Set("Sec-WebSocket-Protocol", "grpc-websockets")
+	if cfg.userAgent != "" {
+		h.Set("User-Agent", cfg.userAgent)
+	}
+	if err := setPerRPCCredentials(context.Background(), cfg.creds, h, u.String()); err != nil {
+		return nil, err
+	}
+	conn, _, err := cfg.webSocketDialer().Dial(u.String(), h)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to dial to '%s'", u.String())
+	}
+
+	return &webSocketTransport{conn: conn}, nil
+}
+
+func (t *webSocketTransport) Header() (http.Header, error) {
+	return t.header, nil
+}
+
+func (t *webSocketTransport) Trailer() http.Header {
+	return t.trailer
+}
+
+func (t *webSocketTransport) SetRequestHeader(h http.Header) {
+	t.reqHeader = h
+}
+
+func (t *webSocketTransport) Send(ctx context.Context, body io.Reader) error {
+	if t.closed {
+		return io.EOF
+	}
+
+	// Unlike the original implementation, the deadline of ctx is respected.
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := t.conn.SetWriteDeadline(deadline); err != nil {
+			return errors.Wrap(err, "failed to set the write deadline")
+		}
+	}
+
+	var err error
+	t.once.Do(func() {
+		h := t.reqHeader
+		if h == nil {
+			h = make(http.Header)
+		}
+		h.Set("content-type", "application/grpc-web+proto")
+		h.Set("x-grpc-web", "1")
+		var b bytes.Buffer
+		if err = h.Write(&b); err != nil {
+			return
+		}
+
+		err = t.writeMessage(websocket.BinaryMessage, b.Bytes())
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to send request headers")
+	}
+
+	var b bytes.Buffer
+	b.Write([]byte{0x00})
+	if _, err := io.Copy(&b, body); err != nil {
+		return errors.Wrap(err, "failed to read request body")
+	}
+
+	return t.writeMessage(websocket.BinaryMessage, b.Bytes())
+}
+
+func (t *webSocketTransport) Receive(ctx context.Context) (_ io.ReadCloser, err error) {
+	if t.closed {
+		return nil, io.EOF
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := t.conn.SetReadDeadline(deadline); err != nil {
+			return nil, errors.Wrap(err, "failed to set the read deadline")
+		}
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && !opErr.Temporary() {
+			err = io.EOF
+		}
+	}()
+
+	// Skip the response header.
+	t.resOnce.Do(func() {
+		_, _, err = t.conn.NextReader()
+		if err != nil {
+			err = errors.Wrap(err, "failed to read response header")
+			return
+		}
+
+		var msg io.Reader
+		_, msg, err = t.conn.NextReader()
+		if err != nil {
+			err = errors.Wrap(err, "failed to read response header")
+			return
+		}
+
+		h := make(http.Header)
+		s := bufio.NewScanner(msg)
+		for s.Scan() {
+			t := s.Text()
+			i := strings.Index(t, ": ")
+			if i == -1 {
+				continue
+			}
+			h.Add(strings.ToLower(t[:i]), t[i+2:])
+		}
+		t.header = h
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	_, b, err := t.conn.ReadMessage()
+	if err != nil {
+		var cerr *websocket.CloseError
+		if errors.As(err, &cerr) {
+			if cerr.Code == websocket.CloseNormalClosure {
+				return nil, io.EOF
+			}
+			if cerr.Code == websocket.CloseAbnormalClosure {
+				return nil, io.ErrUnexpectedEOF
+			}
+		}
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+	buf.Write(b)
+
+	_, r, err := t.conn.NextReader()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(&buf, r); err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+
+	return io.NopCloser(&buf), nil
+}
+
+func (t *webSocketTransport) CloseSend() error {
+	// 0x01 means the finish send frame.
+	// ref. transports/websocket/websocket.ts
+	return t.writeMessage(websocket.BinaryMessage, []byte{0x01})
+}
+
+func (t *webSocketTransport) Close() error {
+	// Send the close message.
+	err := t.writeMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if err != nil {
+		return err
+	}
+	t.closed = true
+	// Close the WebSocket connection.
+	return t.conn.Close()
+}
+
+func (t *webSocketTransport) writeMessage(msg int, b []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	return t.conn.WriteMessage(msg, b)
+}
@@ -0,0 +1,88 @@
+package mode
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ktr0731/evans/cui"
+	"github.com/ktr0731/evans/usecase"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// batchResultJSON is a line of the output of batch calls.
+type batchResultJSON struct {
+	Index    int                     `json:"index"`
+	Status   *usecase.RecordedStatus `json:"status"`
+	Response json.RawMessage         `json:"response,omitempty"`
+}
+
+// callBatch calls the unary method once for each JSON document of the input. The results are written to ui as
+// JSON Lines, and the summary is written to the error output so that it doesn't break the results.
+// It returns an error if one or more calls failed.
+func callBatch(ctx context.Context, ui cui.UI, methodName string, opt *CallCLIInvokerOption) error {
+	var (
+		enc    = json.NewEncoder(ui.Writer())
+		codeN  = make(map[codes.Code]int)
+		failed int
+		start  = time.Now()
+	)
+	err := usecase.Batch(ctx, methodName, &usecase.BatchOption{
+		Concurrency: opt.Concurrency,
+		Timeout:     opt.Timeout,
+	}, func(res *usecase.BatchResult) error {
+		codeN[res.Status.Code()]++
+		if res.Status.Code() != codes.OK {
+			failed++
+		}
+		line := batchResultJSON{
+			Index:  res.Index,
+			Status: &usecase.RecordedStatus{Code: res.Status.Code().String(), Message: res.Status.Message()},
+		}
+		if res.Response != nil {
+			b, err := marshalCompactJSON(res.Response, opt.EmitDefaults)
+			if err != nil {
+				return errors.Wrapf(err, "failed to marshal the response of the request #%d", res.Index)
+			}
+			line.Response = b
+		}
+		return enc.Encode(line)
+	})
+
+	var n int
+	for _, v := range codeN {
+		n += v
+	}
+	summary := make([]string, 0, len(codeN))
+	for _, c := range sortedCodes(codeN) {
+		summary = append(summary, fmt.Sprintf("%s: %d", c, codeN[c]))
+	}
+	ui.Warn(fmt.Sprintf("%d calls finished in %s (%s)", n, time.Since(start).Round(time.Millisecond), strings.Join(summary, ", ")))
+
+	if err != nil {
+		return errors.Wrapf(err, "failed to call RPC '%s' in batch", methodName)
+	}
+	if failed != 0 {
+		return errors.Errorf("%d of %d calls failed", failed, n)
+	}
+	return nil
+}
+
+// marshalCompactJSON marshals m into a line of JSON.
+func marshalCompactJSON(m proto.Message, emitDefaults bool) (json.RawMessage, error) {
+	b, err := protojson.MarshalOptions{EmitUnpopulated: emitDefaults}.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, b); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,185 @@
+package mode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ktr0731/evans/config"
+	"github.com/ktr0731/evans/cui"
+	"github.com/ktr0731/evans/fill"
+	"github.com/ktr0731/evans/usecase"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+)
+
+type BenchCLIInvokerOption struct {
+	Headers     config.Header
+	FilePath    string // If empty, the invoker tries to read input from stdin.
+	FormatType  string // "text" or "json".
+	Timeout     time.Duration
+	Compression string
+	Concurrency int
+	Total       int
+	Duration    time.Duration
+	RPS         int
+}
+
+// NewBenchCLIInvoker returns an CLIInvoker implementation for benchmarking RPCs.
+func NewBenchCLIInvoker(ui cui.UI, methodName string, opt *BenchCLIInvokerOption) (CLIInvoker, error) {
+	if methodName == "" {
+		return nil, errors.New("method is required")
+	}
+	if opt.FormatType != "text" && opt.FormatType != "json" {
+		return nil, errors.Errorf("unknown output format '%s'", opt.FormatType)
+	}
+	return func(ctx context.Context) error {
+		in := DefaultCLIReader
+		if opt.FilePath != "" {
+			f, err := os.Open(opt.FilePath)
+			if err != nil {
+				return errors.Wrap(err, "failed to open the script file")
+			}
+			defer f.Close()
+			in = f
+		}
+		usecase.InjectPartially(usecase.Dependencies{Filler: fill.NewSilentFiller(in)})
+
+		for k, v := range opt.Headers {
+			for _, vv := range v {
+				usecase.AddHeader(k, vv)
+			}
+		}
+		usecase.SetDefaultCompression(opt.Compression)
+
+		methodName, err := useMethod(methodName)
+		if err != nil {
+			return err
+		}
+
+		res, err := usecase.Bench(ctx, methodName, &usecase.BenchOption{
+			Concurrency: opt.Concurrency,
+			Total:       opt.Total,
+			Duration:    opt.Duration,
+			RPS:         opt.RPS,
+			Timeout:     opt.Timeout,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to benchmark RPC '%s'", methodName)
+		}
+
+		report := res.Report()
+		if opt.FormatType == "json" {
+			return writeBenchReportJSON(ui.Writer(), report)
+		}
+		return writeBenchReportText(ui.Writer(), report)
+	}, nil
+}
+
+func writeBenchReportText(w io.Writer, r *usecase.BenchReport) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 1, ' ', 0)
+	fmt.Fprintf(tw, "Summary:\n")
+	fmt.Fprintf(tw, "  Count:\t%d\n", r.Count)
+	fmt.Fprintf(tw, "  Total:\t%s\n", r.Elapsed)
+	fmt.Fprintf(tw, "  Fastest:\t%s\n", r.Fastest)
+	fmt.Fprintf(tw, "  Average:\t%s\n", r.Average)
+	fmt.Fprintf(tw, "  Slowest:\t%s\n", r.Slowest)
+	fmt.Fprintf(tw, "  Requests/sec:\t%.2f\n", r.Throughput)
+	fmt.Fprintf(tw, "\nLatency distribution:\n")
+	fmt.Fprintf(tw, "  p50:\t%s\n", r.P50)
+	fmt.Fprintf(tw, "  p90:\t%s\n", r.P90)
+	fmt.Fprintf(tw, "  p99:\t%s\n", r.P99)
+	fmt.Fprintf(tw, "  max:\t%s\n", r.Slowest)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Histogram) != 0 {
+		// barWidth is the width of the bar for the bucket which has the most calls.
+		const barWidth = 40
+		var max int
+		for _, b := range r.Histogram {
+			if b.Count > max {
+				max = b.Count
+			}
+		}
+		fmt.Fprintf(w, "\nLatency histogram:\n")
+		for _, b := range r.Histogram {
+			fmt.Fprintf(tw, "  %s\t[%d]\t|%s\n", b.Latency, b.Count, strings.Repeat("∎", b.Count*barWidth/max))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(w, "\nStatus code distribution:\n")
+	for _, c := range sortedCodes(r.Codes) {
+		fmt.Fprintf(tw, "  [%s]\t%d responses\n", c, r.Codes[c])
+	}
+	return tw.Flush()
+}
+
+type benchReportJSON struct {
+	Count      int               `json:"count"`
+	Total      string            `json:"total"`
+	Throughput float64           `json:"rps"`
+	Latency    benchLatencyJSON  `json:"latency"`
+	Histogram  []benchBucketJSON `json:"histogram"`
+	Codes      map[string]int    `json:"statusCodes"`
+}
+
+type benchLatencyJSON struct {
+	Fastest string `json:"fastest"`
+	Average string `json:"average"`
+	P50     string `json:"p50"`
+	P90     string `json:"p90"`
+	P99     string `json:"p99"`
+	Max     string `json:"max"`
+}
+
+type benchBucketJSON struct {
+	Latency string `json:"latency"`
+	Count   int    `json:"count"`
+}
+
+func writeBenchReportJSON(w io.Writer, r *usecase.BenchReport) error {
+	out := benchReportJSON{
+		Count:      r.Count,
+		Total:      r.Elapsed.String(),
+		Throughput: r.Throughput,
+		Latency: benchLatencyJSON{
+			Fastest: r.Fastest.String(),
+			Average: r.Average.String(),
+			P50:     r.P50.String(),
+			P90:     r.P90.String(),
+			P99:     r.P99.String(),
+			Max:     r.Slowest.String(),
+		},
+		Histogram: make([]benchBucketJSON, 0, len(r.Histogram)),
+		Codes:     make(map[string]int, len(r.Codes)),
+	}
+	for _, b := range r.Histogram {
+		out.Histogram = append(out.Histogram, benchBucketJSON{Latency: b.Latency.String(), Count: b.Count})
+	}
+	for c, n := range r.Codes {
+		out.Codes[c.String()] = n
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+func sortedCodes(m map[codes.Code]int) []codes.Code {
+	s := make([]codes.Code, 0, len(m))
+	for c := range m {
+		s = append(s, c)
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	return s
+}
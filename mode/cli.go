@@ -4,10 +4,13 @@ import (
 	"context"
 	"io"
 	"os"
+	"os/signal"
+	"strings"
 	"time"
 
 	"github.com/ktr0731/evans/config"
 	"github.com/ktr0731/evans/cui"
+	"github.com/ktr0731/evans/expect"
 	"github.com/ktr0731/evans/fill"
 	"github.com/ktr0731/evans/format"
 	"github.com/ktr0731/evans/format/curl"
@@ -20,6 +23,7 @@ import (
 	"github.com/ktr0731/go-multierror"
 	"github.com/mattn/go-isatty"
 	"github.com/pkg/errors"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 // DefaultCLIReader is the reader that is read for inputting request values. It is exported for E2E testing.
@@ -34,6 +38,18 @@ type CallCLIInvokerOption struct {
 	EmitDefaults bool
 	FilePath     string // If empty, the invoker tries to read input from stdin.
 	FormatType   string
+	Timeout      time.Duration // If 0, no timeout.
+	Compression  string        // If empty, requests are not compressed.
+	RecordFile   string        // If not empty, the call is appended to the capture file.
+	// Batch calls the unary method once for each JSON document of the input, and outputs the results as JSON Lines.
+	Batch bool
+	// Concurrency is the number of concurrent calls in batch mode.
+	Concurrency int
+	// Template renders each message of the input. If nil, the input is decoded verbatim.
+	Template *fill.Template
+	// Expectations are asserted against the result of the call. If nil, nothing is asserted.
+	// Non-OK status is returned as an error unless the status code is expected.
+	Expectations *expect.Expectations
 }
 
 // NewCallCLIInvoker returns an CLIInvoker implementation for calling RPCs.
@@ -51,7 +67,10 @@ func NewCallCLIInvoker(ui cui.UI, methodName string, opt *CallCLIInvokerOption)
 			defer f.Close()
 			in = f
 		}
-		filler := fill.NewSilentFiller(in)
+		var filler fill.Filler = fill.NewSilentFiller(in)
+		if opt.Template != nil {
+			filler = fill.NewTemplateFiller(in, opt.Template)
+		}
 		var rfi format.ResponseFormatterInterface
 		switch opt.FormatType {
 		case "curl":
@@ -71,22 +90,35 @@ func NewCallCLIInvoker(ui cui.UI, methodName string, opt *CallCLIInvokerOption)
 				usecase.AddHeader(k, vv)
 			}
 		}
+		usecase.SetDefaultTimeout(opt.Timeout)
+		usecase.SetDefaultCompression(opt.Compression)
 
-		// Try to parse methodName as a fully-qualified method name.
-		// If it is valid, use its fully-qualified service.
-		fqsn, mtd, err := usecase.ParseFullyQualifiedMethodName(methodName)
-		if err == nil {
-			pkg, svc := proto.ParseFullyQualifiedServiceName(fqsn)
-			if err := usecase.UsePackage(pkg); err != nil {
-				return errors.Wrapf(err, "failed to use package '%s'", pkg)
-			}
-			if err := usecase.UseService(svc); err != nil {
-				return errors.Wrapf(err, "failed to use service '%s'", svc)
-			}
-			methodName = mtd
+		stop, err := startRecording(opt.RecordFile)
+		if err != nil {
+			return err
+		}
+		defer stop()
+
+		// last is the record of the call. It is nil if the call failed before sending requests.
+		var last *usecase.Record
+		if opt.Expectations != nil {
+			usecase.SetCallObserver(func(rec *usecase.Record) { last = rec })
+			defer usecase.SetCallObserver(nil)
+		}
+
+		methodName, err := useMethod(methodName)
+		if err != nil {
+			return err
+		}
+
+		if opt.Batch {
+			return callBatch(ctx, ui, methodName, opt)
 		}
 
 		err = usecase.CallRPC(ctx, ui.Writer(), methodName)
+		if last != nil && (err == nil || opt.Expectations.HasCode()) {
+			return opt.Expectations.Assert(last)
+		}
 		if err != nil {
 			return errors.Wrapf(err, "failed to call RPC '%s'", methodName)
 		}
@@ -94,6 +126,23 @@ func NewCallCLIInvoker(ui cui.UI, methodName string, opt *CallCLIInvokerOption)
 	}, nil
 }
 
+// useMethod selects the package and the service if methodName is a fully-qualified method name.
+// It returns the method name without the service name.
+func useMethod(methodName string) (string, error) {
+	fqsn, mtd, err := usecase.ParseFullyQualifiedMethodName(methodName)
+	if err != nil {
+		return methodName, nil
+	}
+	pkg, svc := proto.ParseFullyQualifiedServiceName(fqsn)
+	if err := usecase.UsePackage(pkg); err != nil {
+		return "", errors.Wrapf(err, "failed to use package '%s'", pkg)
+	}
+	if err := usecase.UseService(svc); err != nil {
+		return "", errors.Wrapf(err, "failed to use service '%s'", svc)
+	}
+	return mtd, nil
+}
+
 func NewListCLIInvoker(ui cui.UI, fqn, format string) CLIInvoker {
 	const (
 		fname = "name"
@@ -178,10 +227,67 @@ func NewDescribeCLIInvoker(ui cui.UI, fqn string) CLIInvoker {
 	}
 }
 
+// NewTemplateCLIInvoker returns an CLIInvoker implementation for printing an example request of the method fqmn.
+func NewTemplateCLIInvoker(ui cui.UI, fqmn string, full bool, depth int, format string) CLIInvoker {
+	return func(context.Context) error {
+		out, err := usecase.FormatRequestTemplate(fqmn, full, depth, format)
+		if err != nil {
+			return errors.Wrap(err, "failed to format the request template")
+		}
+		ui.Output(strings.TrimSuffix(out, "\n"))
+		return nil
+	}
+}
+
+// NewHealthCLIInvoker returns an CLIInvoker implementation for checking the health of the server or the service.
+// If watch is true, the invoker watches the status until the server closes the stream or it is interrupted.
+// The invoker returns an error if the (last) status is not SERVING.
+func NewHealthCLIInvoker(ui cui.UI, service string, watch bool) CLIInvoker {
+	return func(ctx context.Context) error {
+		if !watch {
+			stat, err := usecase.CheckHealth(ctx, service)
+			if err != nil {
+				return err
+			}
+			ui.Output(stat.String())
+			return servingStatusError(service, stat)
+		}
+
+		ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+
+		last := healthpb.HealthCheckResponse_UNKNOWN
+		err := usecase.WatchHealth(ctx, service, func(stat healthpb.HealthCheckResponse_ServingStatus) error {
+			last = stat
+			ui.Output(stat.String())
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return servingStatusError(service, last)
+	}
+}
+
+// servingStatusError returns an error if stat is not SERVING.
+func servingStatusError(service string, stat healthpb.HealthCheckResponse_ServingStatus) error {
+	if stat == healthpb.HealthCheckResponse_SERVING {
+		return nil
+	}
+	if service == "" {
+		return errors.Errorf("the server is %s", stat)
+	}
+	return errors.Errorf("service '%s' is %s", service, stat)
+}
+
 // RunAsCLIMode starts Evans as CLI mode.
 func RunAsCLIMode(cfg *config.Config, invoker CLIInvoker) error {
 	var injectResult error
-	gRPCClient, err := newGRPCClient(cfg)
+	creds, err := newCredentialsProvider(cfg.Credentials)
+	if err != nil {
+		return err
+	}
+	gRPCClient, err := newGRPCClient(cfg, creds)
 	if err != nil {
 		injectResult = multierror.Append(injectResult, err)
 	} else {
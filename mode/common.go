@@ -1,47 +1,169 @@
 package mode
 
 import (
-	"fmt"
+	"crypto/tls"
+	"net/url"
+	"os"
 	"strings"
 
+	"github.com/ktr0731/evans/auth"
 	"github.com/ktr0731/evans/config"
 	"github.com/ktr0731/evans/grpc"
 	"github.com/ktr0731/evans/grpc/grpcreflection"
+	"github.com/ktr0731/evans/meta"
 	"github.com/ktr0731/evans/proto"
 	"github.com/ktr0731/evans/usecase"
 	"github.com/pkg/errors"
 )
 
-func newGRPCClient(cfg *config.Config) (grpc.Client, error) {
-	addr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
-	if cfg.Request.Web {
-		//TODO: remove second arg
-		return grpc.NewWebClient(addr, cfg.Server.Reflection, false, "", "", "", grpc.Headers(cfg.Request.Header)), nil
-	}
-	client, err := grpc.NewClient(
-		addr,
-		cfg.Server.Name,
-		cfg.Server.Reflection,
-		cfg.Server.TLS,
-		cfg.Request.CACertFile,
-		cfg.Request.CertFile,
-		cfg.Request.CertKeyFile,
-		cfg.Request.Header)
+// newGRPCClient instantiates a gRPC client. If creds is not nil, its tokens are attached to each request.
+func newGRPCClient(cfg *config.Config, creds auth.Provider) (grpc.Client, error) {
+	addr := cfg.Server.Addr()
+	if len(cfg.Server.Addresses) != 0 {
+		addr = grpc.StaticTarget(cfg.Server.Addresses)
+	}
+	opts := transportOptions(cfg.Transport)
+	if cfg.Server.LBPolicy != "" {
+		opts = append(opts, grpc.WithLoadBalancingPolicy(cfg.Server.LBPolicy))
+	}
+	if cfg.Request.Proxy != "" {
+		u, err := url.Parse(cfg.Request.Proxy)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse the proxy URL")
+		}
+		opts = append(opts, grpc.WithProxy(u))
+	}
+	if creds != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(creds))
+	}
+	opts = append(opts, tlsOptions(cfg.Request)...)
+	var (
+		client grpc.Client
+		err    error
+	)
+	switch {
+	case cfg.Request.Web:
+		client, err = grpc.NewWebClient(
+			addr,
+			cfg.Server.Name,
+			cfg.Server.Reflection,
+			cfg.Server.TLS,
+			cfg.Request.CACertFile,
+			cfg.Request.CertFile,
+			cfg.Request.CertKeyFile,
+			grpc.Headers(cfg.Request.Header),
+			opts...)
+	case cfg.Request.Connect:
+		client, err = grpc.NewConnectClient(
+			addr,
+			cfg.Server.Name,
+			cfg.Server.Reflection,
+			cfg.Server.TLS,
+			cfg.Request.CACertFile,
+			cfg.Request.CertFile,
+			cfg.Request.CertKeyFile,
+			grpc.Headers(cfg.Request.Header),
+			opts...)
+	default:
+		client, err = grpc.NewClient(
+			addr,
+			cfg.Server.Name,
+			cfg.Server.Reflection,
+			cfg.Server.TLS,
+			cfg.Request.CACertFile,
+			cfg.Request.CertFile,
+			cfg.Request.CertKeyFile,
+			cfg.Request.Header,
+			opts...)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to instantiate a gRPC client")
 	}
 	return client, nil
 }
 
-func gRPCReflectionPackageFilteredPackages(pkgNames []string) []string {
-	pkgs := make([]string, len(pkgNames))
-	copy(pkgs, pkgNames)
+// newCredentialsProvider instantiates the credentials provider specified by cfg.
+// It returns nil if no providers are specified.
+func newCredentialsProvider(cfg *config.Credentials) (auth.Provider, error) {
+	p, err := newCredentialsProviderByName(cfg)
+	if err != nil || p == nil {
+		return p, err
+	}
+	if cfg.AllowInsecure {
+		return auth.AllowInsecure(p), nil
+	}
+	return p, nil
+}
 
-	n := grpcreflection.ServiceName[:strings.LastIndex(grpcreflection.ServiceName, ".")]
-	for i := range pkgs {
-		if n == pkgs[i] {
-			return append(pkgs[:i], pkgs[i+1:]...)
+func newCredentialsProviderByName(cfg *config.Credentials) (auth.Provider, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case "oauth2":
+		return auth.NewOAuth2Provider(cfg.TokenURL, cfg.ClientID, cfg.ClientSecret, cfg.Scopes), nil
+	case "jwt":
+		p, err := auth.NewJWTProvider(cfg.KeyFile, cfg.Issuer, cfg.Subject, cfg.Audience, cfg.TTLDuration())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to instantiate jwt credentials provider")
+		}
+		return p, nil
+	case "exec":
+		p, err := auth.NewExecProvider(cfg.Command, cfg.TTLDuration())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to instantiate exec credentials provider")
 		}
+		return p, nil
+	default:
+		return nil, errors.Errorf("unknown credentials provider '%s'", cfg.Provider)
+	}
+}
+
+// tlsVersions maps the TLS version names in the config to the values of crypto/tls.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsOptions converts the TLS settings in the request config to options for gRPC clients.
+// If $SSLKEYLOGFILE is set, TLS session secrets are written to the file.
+func tlsOptions(cfg *config.Request) []grpc.Option {
+	var opts []grpc.Option
+	if cfg.InsecureSkipVerify {
+		opts = append(opts, grpc.WithInsecureSkipVerify())
+	}
+	if len(cfg.PinSHA256) != 0 {
+		opts = append(opts, grpc.WithPinnedPublicKeys(cfg.PinSHA256))
+	}
+	if v, ok := tlsVersions[cfg.TLSMinVersion]; ok {
+		opts = append(opts, grpc.WithTLSMinVersion(v))
+	}
+	if name := os.Getenv("SSLKEYLOGFILE"); name != "" {
+		opts = append(opts, grpc.WithKeyLogFile(name))
+	}
+	return opts
+}
+
+// transportOptions converts the transport config to options for gRPC clients.
+func transportOptions(cfg *config.Transport) []grpc.Option {
+	ua := "evans/" + meta.Version.String()
+	if cfg.UserAgentSuffix != "" {
+		ua += " " + cfg.UserAgentSuffix
+	}
+	return []grpc.Option{
+		grpc.WithDialTimeout(cfg.DialTimeoutDuration()),
+		grpc.WithMaxMsgSize(cfg.MaxSendMsgSize, cfg.MaxRecvMsgSize),
+		grpc.WithKeepalive(cfg.KeepaliveTimeDuration(), cfg.KeepaliveTimeoutDuration()),
+		grpc.WithInitialWindowSize(cfg.InitialWindowSize, cfg.InitialConnWindowSize),
+		grpc.WithUserAgent(ua),
+	}
+}
+
+func gRPCReflectionPackageFilteredPackages(pkgNames []string) []string {
+	pkgs := pkgNames
+	for _, svc := range grpcreflection.ServiceNames {
+		pkgs = dropString(pkgs, svc[:strings.LastIndex(svc, ".")])
 	}
 	return pkgs
 }
@@ -83,8 +205,10 @@ func setDefault(cfg *config.Config) error {
 			return err
 		}
 
-		// Ignore server reflection name because it's provided imply when reflection is enabled.
-		svcNames = dropString(svcNames, "grpc.reflection.v1alpha.ServerReflection")
+		// Ignore server reflection names because these are provided imply when reflection is enabled.
+		for _, n := range grpcreflection.ServiceNames {
+			svcNames = dropString(svcNames, n)
+		}
 
 		if len(svcNames) != 1 {
 			return nil
@@ -127,3 +251,21 @@ func dropString(slice []string, s string) []string {
 
 	return newSlice
 }
+
+// startRecording starts recording calls to the capture file. Records are appended if the file already exists.
+// The returned func stops recording. Calls aren't recorded if name is empty.
+// The file is readable only by the owner because records have request headers such as credentials.
+func startRecording(name string) (func(), error) {
+	if name == "" {
+		return func() {}, nil
+	}
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open the capture file")
+	}
+	usecase.SetRecorder(f)
+	return func() {
+		usecase.SetRecorder(nil)
+		f.Close()
+	}, nil
+}
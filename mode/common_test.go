@@ -0,0 +1,63 @@
+package mode
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_gRPCReflectionPackageFilteredPackages(t *testing.T) {
+	cases := map[string]struct {
+		pkgs     []string
+		expected []string
+	}{
+		"no reflection packages": {
+			pkgs:     []string{"api"},
+			expected: []string{"api"},
+		},
+		"v1alpha": {
+			pkgs:     []string{"api", "grpc.reflection.v1alpha"},
+			expected: []string{"api"},
+		},
+		"v1": {
+			pkgs:     []string{"grpc.reflection.v1", "api"},
+			expected: []string{"api"},
+		},
+		"both of v1 and v1alpha": {
+			pkgs:     []string{"grpc.reflection.v1", "api", "grpc.reflection.v1alpha"},
+			expected: []string{"api"},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			actual := gRPCReflectionPackageFilteredPackages(c.pkgs)
+			if diff := cmp.Diff(c.expected, actual); diff != "" {
+				t.Errorf("-want, +got\n%s", diff)
+			}
+		})
+	}
+}
+
+func Test_startRecording(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not supported on Windows")
+	}
+	name := filepath.Join(t.TempDir(), "capture.jsonl")
+	stop, err := startRecording(name)
+	if err != nil {
+		t.Fatalf("startRecording must not return an error, but got '%s'", err)
+	}
+	stop()
+
+	fi, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Errorf("the capture file must be readable only by the owner, but got %s", perm)
+	}
+}
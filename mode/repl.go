@@ -2,7 +2,9 @@ package mode
 
 import (
 	"context"
+	"encoding/base64"
 	"sort"
+	"time"
 
 	"github.com/ktr0731/evans/cache"
 	"github.com/ktr0731/evans/config"
@@ -16,12 +18,18 @@ import (
 	"github.com/pkg/errors"
 )
 
-func RunAsREPLMode(cfg *config.Config, ui cui.UI, cache *cache.Cache) error {
-	gRPCClient, err := newGRPCClient(cfg)
+// RunAsREPLMode starts Evans as REPL mode. If recordFile is not empty, all calls are appended to the capture file.
+func RunAsREPLMode(cfg *config.Config, ui cui.UI, cache *cache.Cache, recordFile string) error {
+	creds, err := newCredentialsProvider(cfg.Credentials)
+	if err != nil {
+		return err
+	}
+	gRPCClient, err := newGRPCClient(cfg, creds)
 	if err != nil {
 		return errors.Wrap(err, "failed to instantiate a new gRPC client")
 	}
-	defer gRPCClient.Close(context.Background())
+	// gRPCClient is replaced by connect command.
+	defer func() { gRPCClient.Close(context.Background()) }()
 
 	descSource, err := newDescSource(cfg, gRPCClient)
 	if err != nil {
@@ -34,6 +42,7 @@ func RunAsREPLMode(cfg *config.Config, ui cui.UI, cache *cache.Cache) error {
 			GRPCClient:        gRPCClient,
 			DescSource:        descSource,
 			ResourcePresenter: table.NewPresenter(),
+			Credentials:       creds,
 		},
 	)
 
@@ -44,11 +53,17 @@ func RunAsREPLMode(cfg *config.Config, ui cui.UI, cache *cache.Cache) error {
 		return err
 	}
 
-	for k, v := range cfg.Request.Header {
-		for _, vv := range v {
-			usecase.AddHeader(k, vv)
-		}
+	applyRequestConfig(cfg.Request)
+
+	stop, err := startRecording(recordFile)
+	if err != nil {
+		return err
 	}
+	defer stop()
+
+	// addr is the address of the current server. Previous requests for call --repeat are saved for each server.
+	addr := cfg.Server.Addr()
+	usecase.SetPreviousRequests(loadPreviousRequests(cache, addr))
 
 	replPrompt := prompt.New(prompt.WithCommandHistory(cache.CommandHistory))
 	replPrompt.SetPrefixColor(prompt.ColorBlue)
@@ -56,18 +71,80 @@ func RunAsREPLMode(cfg *config.Config, ui cui.UI, cache *cache.Cache) error {
 	defer func() {
 		history := tidyUpHistory(replPrompt.GetCommandHistory(), cfg.REPL.HistorySize)
 		cache.CommandHistory = history
+		storePreviousRequests(cache, addr, usecase.ListPreviousRequests(), cfg.REPL.PreviousRequestSizeLimit)
 		if err := cache.Save(); err != nil {
 			logger.Printf("failed to write command history: %s", err)
 		}
 	}()
 
-	repl, err := repl.New(cfg, replPrompt, ui, cfg.Default.Package, cfg.Default.Service)
+	connect := func(cfg *config.Config, profile bool) error {
+		client, err := newGRPCClient(cfg, creds)
+		if err != nil {
+			return errors.Wrap(err, "failed to instantiate a new gRPC client")
+		}
+		descSource, err := newDescSource(cfg, client)
+		if err != nil {
+			client.Close(context.Background())
+			return errors.Wrap(err, "failed to instantiate a desc source")
+		}
+
+		usecase.Reconnect(client, descSource)
+		gRPCClient.Close(context.Background())
+		gRPCClient = client
+
+		storePreviousRequests(cache, addr, usecase.ListPreviousRequests(), cfg.REPL.PreviousRequestSizeLimit)
+		addr = cfg.Server.Addr()
+		usecase.SetPreviousRequests(loadPreviousRequests(cache, addr))
+
+		return applyConnectedConfig(cfg, profile)
+	}
+
+	repl, err := repl.New(cfg, replPrompt, ui, cfg.Default.Package, cfg.Default.Service, connect)
 	if err != nil {
 		return errors.Wrap(err, "failed to launch a new REPL")
 	}
 	return repl.Run(ctx)
 }
 
+// applyRequestConfig applies the headers, the timeout and the compressor of req.
+// Headers which req has replace the current values.
+func applyRequestConfig(req *config.Request) {
+	for k, v := range req.Header {
+		usecase.RemoveHeader(k)
+		for _, vv := range v {
+			usecase.AddHeader(k, vv)
+		}
+	}
+	usecase.SetDefaultTimeout(req.TimeoutDuration())
+	usecase.SetDefaultCompression(req.Compression)
+}
+
+// applyConnectedConfig applies cfg after connecting to a new server. If profile is true, the request settings and
+// the default package and service of the profile are applied as if Evans was launched with the profile.
+// Otherwise, the current package and service are kept if the new server has them.
+func applyConnectedConfig(cfg *config.Config, profile bool) error {
+	if profile {
+		applyRequestConfig(cfg.Request)
+		if cfg.Default.Package != "" || cfg.Default.Service != "" {
+			err := setDefault(cfg)
+			if err == nil {
+				return nil
+			}
+			logger.Printf("the default package and service of the profile are not selected: %s", err)
+		}
+	}
+
+	if usecase.GetDomainSourceName() != "" {
+		return nil
+	}
+	// Select the package and the service if the new server has only one.
+	def := *cfg.Default
+	def.Package, def.Service = "", ""
+	c := *cfg
+	c.Default = &def
+	return setDefault(&c)
+}
+
 func tidyUpHistory(h []string, maxHistorySize int) []string {
 	m := make(map[string]int)
 	for i := range h {
@@ -89,3 +166,66 @@ func tidyUpHistory(h []string, maxHistorySize int) []string {
 	}
 	return history
 }
+
+// loadPreviousRequests returns the previous requests sent to server from the cache.
+func loadPreviousRequests(c *cache.Cache, server string) []*usecase.PreviousRequest {
+	var reqs []*usecase.PreviousRequest
+	for _, r := range c.PreviousRequests {
+		if r.Server != server {
+			continue
+		}
+		req := &usecase.PreviousRequest{Method: r.Method}
+		for _, m := range r.Messages {
+			b, err := base64.StdEncoding.DecodeString(m.Payload)
+			if err != nil {
+				logger.Printf("failed to decode the previous request of %s: %s", r.Method, err)
+				req = nil
+				break
+			}
+			interval, _ := time.ParseDuration(m.Interval)
+			req.Messages = append(req.Messages, &usecase.PreviousMessage{Payload: b, Interval: interval})
+		}
+		if req != nil {
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs
+}
+
+// maxStoredPreviousRequests is the maximum number of previous requests in the cache for all servers.
+// The cache is decoded at every startup, so it is kept small.
+const maxStoredPreviousRequests = 100
+
+// storePreviousRequests replaces the previous requests sent to server in the cache with reqs.
+// Requests whose total size exceeds limit bytes are not stored. Nothing is stored if limit is 0.
+// The requests of server are moved to the end of the cache, so the requests of the servers which were used least
+// recently are removed first if the cache has more than maxStoredPreviousRequests requests.
+func storePreviousRequests(c *cache.Cache, server string, reqs []*usecase.PreviousRequest, limit int) {
+	stored := make([]cache.PreviousRequest, 0, len(c.PreviousRequests)+len(reqs))
+	for _, r := range c.PreviousRequests {
+		if r.Server != server {
+			stored = append(stored, r)
+		}
+	}
+	for _, req := range reqs {
+		if limit <= 0 {
+			break
+		}
+		if req.Size() > limit {
+			logger.Printf("the previous request of %s is not saved because it exceeds %d bytes", req.Method, limit)
+			continue
+		}
+		r := cache.PreviousRequest{Server: server, Method: req.Method}
+		for _, m := range req.Messages {
+			r.Messages = append(r.Messages, cache.PreviousMessage{
+				Payload:  base64.StdEncoding.EncodeToString(m.Payload),
+				Interval: m.Interval.String(),
+			})
+		}
+		stored = append(stored, r)
+	}
+	if len(stored) > maxStoredPreviousRequests {
+		stored = stored[len(stored)-maxStoredPreviousRequests:]
+	}
+	c.PreviousRequests = stored
+}
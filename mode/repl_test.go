@@ -1,9 +1,19 @@
 package mode
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/ktr0731/evans/cache"
+	"github.com/ktr0731/evans/config"
+	"github.com/ktr0731/evans/grpc"
+	"github.com/ktr0731/evans/proto"
+	"github.com/ktr0731/evans/usecase"
 )
 
 func Test_tidyUpHistory(t *testing.T) {
@@ -43,3 +53,162 @@ func Test_tidyUpHistory(t *testing.T) {
 		})
 	}
 }
+
+func Test_storeAndLoadPreviousRequests(t *testing.T) {
+	c := &cache.Cache{
+		PreviousRequests: []cache.PreviousRequest{
+			{Server: "example.com:443", Method: "api.Example.Unary", Messages: []cache.PreviousMessage{{Payload: "AA==", Interval: "0s"}}},
+			{Server: "127.0.0.1:50051", Method: "api.Example.Old", Messages: []cache.PreviousMessage{{Payload: "AA==", Interval: "0s"}}},
+		},
+	}
+	reqs := []*usecase.PreviousRequest{
+		{
+			Method: "api.Example.ClientStreaming",
+			Messages: []*usecase.PreviousMessage{
+				{Payload: []byte("foo")},
+				{Payload: []byte("bar"), Interval: 1500 * time.Millisecond},
+			},
+		},
+		{
+			Method:   "api.Example.Large",
+			Messages: []*usecase.PreviousMessage{{Payload: []byte("0123456789")}},
+		},
+	}
+
+	storePreviousRequests(c, "127.0.0.1:50051", reqs, 8)
+
+	if n := len(c.PreviousRequests); n != 2 {
+		t.Fatalf("requests of other servers must be kept and too large requests must be dropped, but got %d requests", n)
+	}
+	if diff := cmp.Diff(reqs[:1], loadPreviousRequests(c, "127.0.0.1:50051")); diff != "" {
+		t.Errorf("-want, +got\n%s", diff)
+	}
+	if n := len(loadPreviousRequests(c, "example.com:443")); n != 1 {
+		t.Errorf("expected 1 request for example.com:443, but got %d", n)
+	}
+
+	storePreviousRequests(c, "example.com:443", reqs, 0)
+	if n := len(loadPreviousRequests(c, "example.com:443")); n != 0 {
+		t.Errorf("nothing must be stored if the limit is 0, but got %d requests", n)
+	}
+}
+
+func Test_storePreviousRequests_evictsOldestServers(t *testing.T) {
+	c := &cache.Cache{}
+	newRequests := func(n int) []*usecase.PreviousRequest {
+		reqs := make([]*usecase.PreviousRequest, 0, n)
+		for i := 0; i < n; i++ {
+			reqs = append(reqs, &usecase.PreviousRequest{
+				Method:   fmt.Sprintf("api.Example.Method%d", i),
+				Messages: []*usecase.PreviousMessage{{Payload: []byte("foo")}},
+			})
+		}
+		return reqs
+	}
+
+	storePreviousRequests(c, "old.example.com:443", newRequests(maxStoredPreviousRequests/2), 1024)
+	storePreviousRequests(c, "example.com:443", newRequests(maxStoredPreviousRequests/2), 1024)
+	// Storing the requests of old.example.com:443 again makes example.com:443 the least recently used.
+	storePreviousRequests(c, "old.example.com:443", newRequests(maxStoredPreviousRequests/2), 1024)
+	storePreviousRequests(c, "127.0.0.1:50051", newRequests(10), 1024)
+
+	if n := len(c.PreviousRequests); n != maxStoredPreviousRequests {
+		t.Fatalf("expected %d requests, but got %d", maxStoredPreviousRequests, n)
+	}
+	if n := len(loadPreviousRequests(c, "example.com:443")); n != maxStoredPreviousRequests/2-10 {
+		t.Errorf("the requests of the least recently used server must be removed first, but got %d requests", n)
+	}
+	if n := len(loadPreviousRequests(c, "old.example.com:443")); n != maxStoredPreviousRequests/2 {
+		t.Errorf("expected %d requests for old.example.com:443, but got %d", maxStoredPreviousRequests/2, n)
+	}
+	if n := len(loadPreviousRequests(c, "127.0.0.1:50051")); n != 10 {
+		t.Errorf("expected 10 requests for 127.0.0.1:50051, but got %d", n)
+	}
+}
+
+func Test_applyConnectedConfig(t *testing.T) {
+	dir := t.TempDir()
+	protos := map[string]string{
+		"api.proto":   `syntax = "proto3"; package api; message Req {} service Example { rpc Unary(Req) returns (Req); }`,
+		"admin.proto": `syntax = "proto3"; package admin; message Req {} service User { rpc Get(Req) returns (Req); } service Group { rpc Get(Req) returns (Req); }`,
+	}
+	for name, content := range protos {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	descSource, err := proto.NewDescriptorSourceFromFiles([]string{dir}, []string{"api.proto", "admin.proto"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := grpc.NewClient("127.0.0.1:50051", "", false, false, "", "", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close(context.Background()) })
+
+	newConfig := func() *config.Config {
+		return &config.Config{
+			Default: &config.Default{Package: "admin", Service: "Group"},
+			Request: &config.Request{
+				Header:      config.Header{"env": []string{"staging"}},
+				Timeout:     "3s",
+				Compression: "gzip",
+			},
+		}
+	}
+	cases := map[string]struct {
+		cfg     func() *config.Config
+		profile bool
+
+		expectedDSN    string
+		expectedHeader []string
+	}{
+		"profile": {
+			cfg:            newConfig,
+			profile:        true,
+			expectedDSN:    "admin.Group",
+			expectedHeader: []string{"staging"},
+		},
+		"profile which has an unknown package": {
+			cfg: func() *config.Config {
+				cfg := newConfig()
+				cfg.Default.Package = "unknown"
+				return cfg
+			},
+			profile:        true,
+			expectedDSN:    "api.Example",
+			expectedHeader: []string{"staging"},
+		},
+		"not profile": {
+			cfg:            newConfig,
+			expectedDSN:    "api.Example",
+			expectedHeader: []string{"dev"},
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			defer usecase.Clear()
+			usecase.Inject(usecase.Dependencies{GRPCClient: client, DescSource: descSource})
+			client.Header().Remove("env")
+			usecase.AddHeader("env", "dev")
+			if err := usecase.UsePackage("api"); err != nil {
+				t.Fatal(err)
+			}
+			if err := usecase.UseService("Example"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := applyConnectedConfig(c.cfg(), c.profile); err != nil {
+				t.Fatalf("applyConnectedConfig must not return an error, but got '%s'", err)
+			}
+			if dsn := usecase.GetDomainSourceName(); dsn != c.expectedDSN {
+				t.Errorf("expected '%s', but got '%s'", c.expectedDSN, dsn)
+			}
+			if diff := cmp.Diff(c.expectedHeader, usecase.ListHeaders()["env"]); diff != "" {
+				t.Errorf("-want, +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package mode
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ktr0731/evans/config"
+	"github.com/ktr0731/evans/cui"
+	"github.com/ktr0731/evans/usecase"
+	"github.com/pkg/errors"
+)
+
+type ReplayCLIInvokerOption struct {
+	Headers     config.Header
+	Timeout     time.Duration
+	Compression string
+}
+
+// NewReplayCLIInvoker returns an CLIInvoker implementation for replaying the calls recorded in the capture file.
+// The invoker returns an error if one or more replayed calls are different from the records.
+func NewReplayCLIInvoker(ui cui.UI, filePath string, opt *ReplayCLIInvokerOption) (CLIInvoker, error) {
+	if filePath == "" {
+		return nil, errors.New("capture file is required")
+	}
+	return func(ctx context.Context) error {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return errors.Wrap(err, "failed to open the capture file")
+		}
+		defer f.Close()
+
+		for k, v := range opt.Headers {
+			for _, vv := range v {
+				usecase.AddHeader(k, vv)
+			}
+		}
+		usecase.SetDefaultTimeout(opt.Timeout)
+		usecase.SetDefaultCompression(opt.Compression)
+
+		var n, diffs int
+		err = usecase.Replay(ctx, f, func(res *usecase.ReplayResult) error {
+			n++
+			if len(res.Diffs) == 0 {
+				ui.Output(fmt.Sprintf("OK   %s", res.Record.Method))
+				return nil
+			}
+			diffs++
+			ui.Output(fmt.Sprintf("DIFF %s", res.Record.Method))
+			for _, d := range res.Diffs {
+				ui.Output("  " + d)
+			}
+			return nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to replay")
+		}
+
+		ui.Output(fmt.Sprintf("\n%d calls replayed, %d differ from the records", n, diffs))
+		if diffs != 0 {
+			return errors.Errorf("%d calls differ from the records", diffs)
+		}
+		return nil
+	}, nil
+}
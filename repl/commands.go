@@ -5,15 +5,23 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
+	"github.com/ktr0731/evans/config"
+	"github.com/ktr0731/evans/fill"
 	"github.com/ktr0731/evans/format"
 	"github.com/ktr0731/evans/format/curl"
 	"github.com/ktr0731/evans/idl"
 	"github.com/ktr0731/evans/usecase"
 	"github.com/pkg/errors"
 	"github.com/spf13/pflag"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 var (
@@ -109,7 +117,7 @@ func (c *showCommand) Synopsis() string {
 }
 
 func (c *showCommand) Help() string {
-	return "usage: show <package | service | message | rpc | header>"
+	return "usage: show <package | service | message | rpc | header | credentials>"
 }
 
 func (c *showCommand) FlagSet() (*pflag.FlagSet, bool) {
@@ -139,6 +147,8 @@ func (c *showCommand) Run(w io.Writer, args []string) error {
 		f = usecase.FormatMethods
 	case "h", "header", "headers":
 		f = usecase.FormatHeaders
+	case "c", "cred", "credentials":
+		f = usecase.FormatCredentials
 	default:
 		return errors.Errorf("unknown target '%s'", target)
 	}
@@ -155,7 +165,12 @@ func (c *showCommand) Run(w io.Writer, args []string) error {
 }
 
 type callCommand struct {
-	enrich, digManually, bytesAsBase64, bytesAsQuotedLiterals, bytesFromFile, emitDefaults, repeatCall, addRepeatedManually bool
+	enrich, digManually, bytesAsBase64, bytesAsQuotedLiterals, bytesFromFile, emitDefaults, repeatCall, repeatTiming, addRepeatedManually, edit bool
+
+	timeout     time.Duration
+	compression string
+	template    bool
+	vars        []string
 }
 
 func (c *callCommand) FlagSet() (*pflag.FlagSet, bool) {
@@ -167,8 +182,14 @@ func (c *callCommand) FlagSet() (*pflag.FlagSet, bool) {
 	fs.BoolVar(&c.bytesAsQuotedLiterals, "bytes-as-quoted-literals", false, "interpret TYPE_BYTES input as a string of (quoted) byte literal or Unicode (mutually exclusive with --bytes-from-file and --bytes-as-base64)")
 	fs.BoolVar(&c.bytesFromFile, "bytes-from-file", false, "interpret TYPE_BYTES input as a relative path to a file (mutually exclusive with --bytes-as-base64)")
 	fs.BoolVar(&c.emitDefaults, "emit-defaults", false, "render fields with default values")
-	fs.BoolVarP(&c.repeatCall, "repeat", "r", false, "repeat previous unary or server streaming request (if exists)")
+	fs.BoolVarP(&c.repeatCall, "repeat", "r", false, "repeat previous request (if exists). all messages are resent for client and bidi streaming RPCs")
+	fs.BoolVar(&c.repeatTiming, "repeat-timing", false, "with --repeat, resend stream messages at the same intervals as the previous call")
 	fs.BoolVar(&c.addRepeatedManually, "add-repeated-manually", false, "prompt asks whether to add a value if it encountered to a repeated field")
+	fs.DurationVar(&c.timeout, "timeout", 0, "timeout for the RPC such as 10s (default request.timeout in the config)")
+	fs.StringVar(&c.compression, "compression", "", "compress requests with gzip, zstd or snappy (default request.compression in the config)")
+	fs.BoolVar(&c.template, "template", false, `render each input as a template such as {{ uuid }} or {{ env "NAME" }}`)
+	fs.StringArrayVar(&c.vars, "var", nil, "a variable for --template formed as key=value, referred as {{ .key }}")
+	fs.BoolVarP(&c.edit, "edit", "e", false, "write requests as JSON in $EDITOR instead of the prompt. prefilled with the previous request (if exists)")
 	return fs, true
 }
 
@@ -209,10 +230,43 @@ func (c *callCommand) Run(w io.Writer, args []string) error {
 	if c.bytesAsBase64 && c.bytesAsQuotedLiterals {
 		return errors.New("only one of --bytes-as-base64 or --bytes-as-quoted-literals can be specified")
 	}
+	if c.timeout < 0 {
+		return errors.New("--timeout must be a non-negative duration such as 10s")
+	}
+
+	var tmpl *fill.Template
+	if c.template {
+		vars, err := fill.ParseVars(c.vars)
+		if err != nil {
+			return err
+		}
+		tmpl = fill.NewTemplate(vars)
+	}
+
+	if c.repeatTiming && !c.repeatCall {
+		return errors.New("--repeat-timing can be specified only with --repeat")
+	}
+	if c.edit && (c.repeatCall || c.template) {
+		return errors.New("--edit cannot be specified with --repeat or --template")
+	}
+	if c.edit {
+		err := usecase.CallRPCWithEditor(context.Background(), w, args[0], editText, c.timeout, c.compression)
+		if errors.Is(err, io.EOF) {
+			return errors.New("inputting canceled")
+		}
+		return err
+	}
 
 	// here we create the request context
 	// we also add the call command flags here
-	err := usecase.CallRPCInteractively(context.Background(), w, args[0], c.digManually, c.bytesAsBase64, c.bytesAsQuotedLiterals, c.bytesFromFile, c.repeatCall, c.addRepeatedManually)
+	// Waiting for the intervals of the previous call can be interrupted by Ctrl-C.
+	ctx := context.Background()
+	if c.repeatTiming {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+	}
+	err := usecase.CallRPCInteractively(ctx, w, args[0], c.digManually, c.bytesAsBase64, c.bytesAsQuotedLiterals, c.bytesFromFile, c.repeatCall, c.repeatTiming, c.addRepeatedManually, c.timeout, c.compression, tmpl)
 	if errors.Is(err, io.EOF) {
 		return errors.New("inputting canceled")
 	}
@@ -279,6 +333,269 @@ func (c *headerCommand) Run(_ io.Writer, args []string) error {
 	return nil
 }
 
+// ConnectFunc connects to the server specified by cfg.
+// It replaces the current gRPC client and the descriptor source if it succeeded.
+// profile is true if cfg is a profile. The request settings and the default package and service of the profile are
+// also applied.
+type ConnectFunc func(cfg *config.Config, profile bool) error
+
+type connectCommand struct {
+	cfg     *config.Config
+	connect ConnectFunc
+
+	fs                     *pflag.FlagSet
+	tls, reflection        bool
+	serverName, caCertFile string
+}
+
+func (c *connectCommand) FlagSet() (*pflag.FlagSet, bool) {
+	fs := pflag.NewFlagSet("connect", pflag.ContinueOnError)
+	fs.Usage = func() {} // Disable help output when an error occurred.
+	fs.BoolVar(&c.tls, "tls", c.cfg.Server.TLS, "use a secure TLS connection")
+	fs.BoolVarP(&c.reflection, "reflection", "r", c.cfg.Server.Reflection, "use gRPC reflection")
+	fs.StringVar(&c.serverName, "servername", c.cfg.Server.Name, "override the server name used to verify the hostname")
+	fs.StringVar(&c.caCertFile, "cacert", c.cfg.Request.CACertFile, "the CA certificate file for verifying the server")
+	c.fs = fs
+	return fs, true
+}
+
+func (c *connectCommand) Synopsis() string {
+	return "connect to another server"
+}
+
+func (c *connectCommand) Help() string {
+	var buf bytes.Buffer
+	fs, _ := c.FlagSet()
+	fs.SetOutput(&buf)
+	fs.PrintDefaults()
+	return fmt.Sprintf(`usage: connect [options ...] <host:port | target | profile>
+
+Options:
+%s`, strings.TrimRightFunc(buf.String(), unicode.IsSpace))
+}
+
+func (c *connectCommand) Validate(args []string) error {
+	if len(args) < 1 {
+		return errArgumentRequired
+	}
+	return nil
+}
+
+func (c *connectCommand) Run(_ io.Writer, args []string) error {
+	cfg, err := c.newConfig(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Flags override the profile or the current config only if they are specified.
+	if c.fs.Changed("tls") {
+		cfg.Server.TLS = c.tls
+	}
+	if c.fs.Changed("reflection") {
+		cfg.Server.Reflection = c.reflection
+	}
+	if c.fs.Changed("servername") {
+		cfg.Server.Name = c.serverName
+	}
+	if c.fs.Changed("cacert") {
+		cfg.Request.CACertFile = c.caCertFile
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	_, profile := c.cfg.Profiles[strings.ToLower(args[0])]
+	if err := c.connect(cfg, profile); err != nil {
+		return errors.Wrapf(err, "failed to connect to %s", cfg.Server.Addr())
+	}
+
+	// Update the config in place so that the prompt shows the new address.
+	*c.cfg.Default, *c.cfg.Server, *c.cfg.Request = *cfg.Default, *cfg.Server, *cfg.Request
+	return nil
+}
+
+// newConfig returns a copy of the current config which points to target.
+// The current config is kept as it is until the connection succeeds.
+func (c *connectCommand) newConfig(target string) (*config.Config, error) {
+	if _, ok := c.cfg.Profiles[strings.ToLower(target)]; ok {
+		return c.cfg.ApplyProfile(target)
+	}
+
+	cfg := *c.cfg
+	def, srv, req := *c.cfg.Default, *c.cfg.Server, *c.cfg.Request
+	cfg.Default, cfg.Server, cfg.Request = &def, &srv, &req
+
+	srv.Target, srv.Addresses = "", nil
+	if strings.Contains(target, "://") {
+		srv.Target = target
+		return &cfg, nil
+	}
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, errors.Errorf("invalid address '%s', it must be host:port, a gRPC dial target or a profile name", target)
+	}
+	srv.Host, srv.Port = host, port
+	return &cfg, nil
+}
+
+type healthCommand struct {
+	watch bool
+}
+
+func (c *healthCommand) FlagSet() (*pflag.FlagSet, bool) {
+	fs := pflag.NewFlagSet("health", pflag.ContinueOnError)
+	fs.Usage = func() {} // Disable help output when an error occurred.
+	fs.BoolVarP(&c.watch, "watch", "w", false, "watch status changes until the server closes the stream or interrupted by Ctrl-C")
+	return fs, true
+}
+
+func (c *healthCommand) Synopsis() string {
+	return "check the health of the server or the service"
+}
+
+func (c *healthCommand) Help() string {
+	var buf bytes.Buffer
+	fs, _ := c.FlagSet()
+	fs.SetOutput(&buf)
+	fs.PrintDefaults()
+	return fmt.Sprintf(`usage: health [options ...] [service name]
+
+Options:
+%s`, strings.TrimRightFunc(buf.String(), unicode.IsSpace))
+}
+
+func (c *healthCommand) Validate([]string) error { return nil }
+
+func (c *healthCommand) Run(w io.Writer, args []string) error {
+	var service string
+	if len(args) > 0 {
+		service = args[0]
+	}
+
+	if !c.watch {
+		stat, err := usecase.CheckHealth(context.Background(), service)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, stat)
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return usecase.WatchHealth(ctx, service, func(stat healthpb.HealthCheckResponse_ServingStatus) error {
+		_, err := fmt.Fprintln(w, stat)
+		return err
+	})
+}
+
+type historyCommand struct {
+	n int
+}
+
+func (c *historyCommand) Synopsis() string {
+	return "show, resend or edit past calls"
+}
+
+func (c *historyCommand) Help() string {
+	var buf bytes.Buffer
+	fs, _ := c.FlagSet()
+	fs.SetOutput(&buf)
+	fs.PrintDefaults()
+	return fmt.Sprintf(`usage: history [options ...] [show <#> | resend <#> | edit <#> | clear]
+
+history lists the last calls if no subcommands are passed.
+
+Subcommands:
+  show <#>      show the requests of the call as JSON
+  resend <#>    call the method again with the same requests
+  edit <#>      edit the requests of the call with $EDITOR, and call the method with them
+  clear         remove the previous requests of all methods sent to the current server
+
+Options:
+%s`, strings.TrimRightFunc(buf.String(), unicode.IsSpace))
+}
+
+func (c *historyCommand) FlagSet() (*pflag.FlagSet, bool) {
+	fs := pflag.NewFlagSet("history", pflag.ContinueOnError)
+	fs.Usage = func() {} // Disable help output when an error occurred.
+	fs.IntVarP(&c.n, "num", "n", 10, "the number of calls listed")
+	return fs, true
+}
+
+func (c *historyCommand) Validate(args []string) error {
+	if len(args) == 0 {
+		return nil
+	}
+	switch args[0] {
+	case "show", "resend", "edit":
+		if len(args) < 2 {
+			return errArgumentRequired
+		}
+	}
+	return nil
+}
+
+func (c *historyCommand) Run(w io.Writer, args []string) error {
+	if len(args) == 0 {
+		out, err := usecase.FormatCallHistory(c.n)
+		if err != nil {
+			return errors.Wrap(err, "failed to format")
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	}
+
+	if args[0] == "clear" {
+		n := usecase.ClearPreviousRequests()
+		unit := "methods"
+		if n == 1 {
+			unit = "method"
+		}
+		_, err := fmt.Fprintf(w, "removed the previous requests of %d %s\n", n, unit)
+		return err
+	}
+
+	var id int
+	switch args[0] {
+	case "show", "resend", "edit":
+		var err error
+		id, err = strconv.Atoi(strings.TrimPrefix(args[1], "#"))
+		if err != nil {
+			return errors.Errorf("invalid call number '%s'", args[1])
+		}
+	default:
+		return errors.Errorf("unknown subcommand '%s'", args[0])
+	}
+
+	usecase.InjectPartially(
+		usecase.Dependencies{
+			ResponseFormatter: format.NewResponseFormatter(curl.NewResponseFormatter(w, false), false),
+		},
+	)
+	switch args[0] {
+	case "show":
+		out, err := usecase.FormatCallHistoryRequests(id)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	case "resend":
+		return usecase.ResendCall(context.Background(), w, id, nil)
+	default: // edit
+		text, err := usecase.FormatCallHistoryRequests(id)
+		if err != nil {
+			return err
+		}
+		edited, err := editText(text)
+		if err != nil {
+			return err
+		}
+		return usecase.ResendCall(context.Background(), w, id, strings.NewReader(edited))
+	}
+}
+
 type exitCommand struct{}
 
 func (c *exitCommand) Synopsis() string {
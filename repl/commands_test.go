@@ -1,6 +1,12 @@
 package repl
 
-import "testing"
+import (
+	"io"
+	"testing"
+
+	"github.com/ktr0731/evans/config"
+	"github.com/pkg/errors"
+)
 
 func TestValidate(t *testing.T) {
 	type testCase struct {
@@ -56,6 +62,20 @@ func TestValidate(t *testing.T) {
 				{args: []string{}, hasErr: true},
 			},
 		},
+		"connect": cmdTestCase{
+			cmd: &connectCommand{},
+			testCases: []testCase{
+				{args: []string{"localhost:50051"}},
+				{args: []string{}, hasErr: true},
+			},
+		},
+		"health": cmdTestCase{
+			cmd: &healthCommand{},
+			testCases: []testCase{
+				{args: []string{"kumiko"}},
+				{args: []string{}},
+			},
+		},
 		"exit": cmdTestCase{
 			cmd: &exitCommand{},
 			testCases: []testCase{
@@ -80,3 +100,79 @@ func TestValidate(t *testing.T) {
 		}
 	}
 }
+
+func TestConnectCommand_Run(t *testing.T) {
+	newConfig := func() *config.Config {
+		return &config.Config{
+			Default:     &config.Default{},
+			Server:      &config.Server{Host: "127.0.0.1", Port: "50051", Reflection: true},
+			Request:     &config.Request{},
+			Transport:   &config.Transport{},
+			Credentials: &config.Credentials{},
+			Profiles: map[string]config.Profile{
+				"staging": {"server": map[string]interface{}{"host": "staging.example.com", "port": "443", "tls": true}},
+			},
+		}
+	}
+
+	cases := map[string]struct {
+		args       []string
+		connectErr error
+
+		expectedAddr    string
+		expectedTLS     bool
+		expectedProfile bool
+		hasErr          bool
+	}{
+		"host and port":               {args: []string{"localhost:8080"}, expectedAddr: "localhost:8080"},
+		"dial target":                 {args: []string{"unix:///tmp/grpc.sock"}, expectedAddr: "unix:///tmp/grpc.sock"},
+		"with TLS":                    {args: []string{"--tls", "localhost:443"}, expectedAddr: "localhost:443", expectedTLS: true},
+		"invalid address":             {args: []string{"localhost"}, expectedAddr: "127.0.0.1:50051", hasErr: true},
+		"invalid config":              {args: []string{"-r=false", "localhost:8080"}, expectedAddr: "127.0.0.1:50051", hasErr: true},
+		"profile":                     {args: []string{"staging"}, expectedAddr: "staging.example.com:443", expectedTLS: true, expectedProfile: true},
+		"profile overridden by flags": {args: []string{"--tls=false", "staging"}, expectedAddr: "staging.example.com:443", expectedProfile: true},
+		"failed to connect":           {args: []string{"localhost:8080"}, connectErr: errors.New("an error"), expectedAddr: "127.0.0.1:50051", hasErr: true},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			cfg := newConfig()
+			var (
+				connected *config.Config
+				profile   bool
+			)
+			cmd := &connectCommand{cfg: cfg, connect: func(cfg *config.Config, p bool) error {
+				connected, profile = cfg, p
+				return c.connectErr
+			}}
+			fs, _ := cmd.FlagSet()
+			if err := fs.Parse(c.args); err != nil {
+				t.Fatalf("Parse must not return an error, but got '%s'", err)
+			}
+
+			err := cmd.Run(io.Discard, fs.Args())
+			if c.hasErr {
+				if err == nil {
+					t.Errorf("Run must return an error, but got nil")
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Run must not return an error, but got '%s'", err)
+				}
+				if connected.Server.Addr() != c.expectedAddr {
+					t.Errorf("connect must be called with '%s', but got '%s'", c.expectedAddr, connected.Server.Addr())
+				}
+				if profile != c.expectedProfile {
+					t.Errorf("expected profile %t, but got %t", c.expectedProfile, profile)
+				}
+			}
+
+			if actual := cfg.Server.Addr(); actual != c.expectedAddr {
+				t.Errorf("expected '%s', but got '%s'", c.expectedAddr, actual)
+			}
+			if cfg.Server.TLS != c.expectedTLS {
+				t.Errorf("expected TLS %t, but got %t", c.expectedTLS, cfg.Server.TLS)
+			}
+		})
+	}
+}
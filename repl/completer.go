@@ -84,6 +84,18 @@ func newCompleter(cmds map[string]commander) *completer {
 						prompt.NewSuggestion("message", "show loaded message names"),
 						prompt.NewSuggestion("rpc", "show RPC names belonging to the current selected service"),
 						prompt.NewSuggestion("header", "show headers which will be added to each request"),
+						prompt.NewSuggestion("credentials", "show the active credentials provider"),
+					}
+				}
+				return s
+			},
+			"history": func(args []string) (s []*prompt.Suggest) {
+				if len(args) == 1 {
+					s = []*prompt.Suggest{
+						prompt.NewSuggestion("show", "show the requests of the call as JSON"),
+						prompt.NewSuggestion("resend", "call the method again with the same requests"),
+						prompt.NewSuggestion("edit", "edit the requests of the call with $EDITOR, and call the method with them"),
+						prompt.NewSuggestion("clear", "remove the previous requests of all methods sent to the current server"),
 					}
 				}
 				return s
@@ -0,0 +1,50 @@
+package repl
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/ktr0731/go-shellstring"
+	"github.com/pkg/errors"
+)
+
+// editText opens text with an editor, and returns the edited text.
+// $EDITOR is used as the editor if it is set. Else, Vim is used. $EDITOR may have arguments such as "code --wait".
+func editText(text string) (string, error) {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		p, err := exec.LookPath("vim")
+		if err != nil {
+			return "", errors.New("editing requires one of $EDITOR value or Vim")
+		}
+		editor = p
+	}
+	cmd, err := shellstring.Parse(editor)
+	if err != nil || len(cmd) == 0 {
+		return "", errors.Errorf("invalid $EDITOR '%s'", editor)
+	}
+
+	f, err := os.CreateTemp("", "evans-*.json")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create a temp file")
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return "", errors.Wrap(err, "failed to write to the temp file")
+	}
+	if err := f.Close(); err != nil {
+		return "", errors.Wrap(err, "failed to close the temp file")
+	}
+
+	c := exec.Command(cmd[0], append(cmd[1:], f.Name())...) //nolint:gosec
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return "", errors.Wrapf(err, "failed to execute %s", editor)
+	}
+	b, err := os.ReadFile(f.Name())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read the edited file")
+	}
+	return string(b), nil
+}
@@ -38,6 +38,8 @@ var commands = map[string]commander{
 	"header":  &headerCommand{},
 	"package": &packageCommand{},
 	"show":    &showCommand{},
+	"health":  &healthCommand{},
+	"history": &historyCommand{},
 	"exit":    &exitCommand{},
 
 	// Depends to Protocol Buffers.
@@ -45,9 +47,16 @@ var commands = map[string]commander{
 }
 
 // New instantiates a new REPL instance. New always calls p.SetPrefix for display the server addr.
+// If connect is not nil, connect command is available.
 // New may return an error if some of passed arguments are invalid.
-func New(cfg *config.Config, p prompt.Prompt, ui cui.UI, pkgName, svcName string) (*REPL, error) {
-	cmds := commands
+func New(cfg *config.Config, p prompt.Prompt, ui cui.UI, pkgName, svcName string, connect ConnectFunc) (*REPL, error) {
+	cmds := make(map[string]commander, len(commands)+1)
+	for name, cmd := range commands {
+		cmds[name] = cmd
+	}
+	if connect != nil {
+		cmds["connect"] = &connectCommand{cfg: cfg, connect: connect}
+	}
 	// Each value must be a key of cmds.
 	aliases := map[string]string{
 		"quit": "exit",
@@ -188,7 +197,7 @@ func (r *REPL) printSplash(p string) {
 }
 
 func (r *REPL) makePrefix() string {
-	p := fmt.Sprintf("%s:%s> ", r.serverCfg.Host, r.serverCfg.Port)
+	p := fmt.Sprintf("%s> ", r.serverCfg.Addr())
 	dsn := usecase.GetDomainSourceName()
 	if dsn != "" {
 		p = fmt.Sprintf("%s@%s", dsn, p)
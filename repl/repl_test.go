@@ -21,7 +21,7 @@ func TestREPL_helpText(t *testing.T) {
 
 	usecase.Clear()
 
-	r, err := New(dummyCfg, prompt.New(), nil, "", "")
+	r, err := New(dummyCfg, prompt.New(), nil, "", "", nil)
 	if err != nil {
 		t.Fatalf("New must not return an erorr, but got '%s'", err)
 	}
@@ -40,7 +40,7 @@ func TestREPL_printSplash(t *testing.T) {
 	w := new(bytes.Buffer)
 	ui := cui.New(cui.Writer(w))
 
-	r, err := New(dummyCfg, prompt.New(), ui, "", "")
+	r, err := New(dummyCfg, prompt.New(), ui, "", "", nil)
 	if err != nil {
 		t.Fatalf("New must not return an erorr, but got '%s'", err)
 	}
@@ -87,12 +87,18 @@ func TestREPL_makePrefix(t *testing.T) {
 	cases := map[string]struct {
 		pkgName string
 		svcName string
+		target  string
 
 		hasErr   bool
 		expected string
 	}{
 		"package and service unselected": {expected: "127.0.0.1:50051> "},
-		"package selected":               {pkgName: "api", expected: "api@127.0.0.1:50051> "},
+		"target specified": {
+			pkgName:  "api",
+			target:   "unix:///tmp/evans.sock",
+			expected: "api@unix:///tmp/evans.sock> ",
+		},
+		"package selected": {pkgName: "api", expected: "api@127.0.0.1:50051> "},
 		"package and service selected": {
 			pkgName:  "api",
 			svcName:  "Example",
@@ -104,7 +110,7 @@ func TestREPL_makePrefix(t *testing.T) {
 		c := c
 		dummyCfg := &config.Config{
 			REPL:   &config.REPL{},
-			Server: &config.Server{Host: "127.0.0.1", Port: "50051"},
+			Server: &config.Server{Host: "127.0.0.1", Port: "50051", Target: c.target},
 		}
 		dummyDescSource := &proto.DescriptorSourceMock{
 			ListServicesFunc: func() ([]string, error) { return []string{"api.Example"}, nil },
@@ -112,7 +118,7 @@ func TestREPL_makePrefix(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			usecase.Inject(usecase.Dependencies{DescSource: dummyDescSource})
 
-			r, err := New(dummyCfg, prompt.New(), nil, c.pkgName, c.svcName)
+			r, err := New(dummyCfg, prompt.New(), nil, c.pkgName, c.svcName, nil)
 			if c.hasErr {
 				if err == nil {
 					t.Errorf("New must return an error, but got nil")
@@ -138,6 +144,8 @@ Available commands:
   desc       describe the structure of selected message
   exit       exit current REPL
   header     set/unset headers to each request. if header value is empty, the header is removed.
+  health     check the health of the server or the service
+  history    show, resend or edit past calls
   package    set a package as the currently selected package
   service    set the service as the current selected service
   show       show package, service or RPC names
@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	pb "github.com/ktr0731/evans/proto"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// BatchOption is the option for Batch.
+type BatchOption struct {
+	// Concurrency is the number of workers that call the RPC concurrently. 1 if it is 0.
+	Concurrency int
+	// Timeout is the timeout for each call. If it is 0, the default timeout is used.
+	Timeout time.Duration
+}
+
+// BatchResult is the result of a call of Batch.
+type BatchResult struct {
+	// Index is the zero-based index of the request in the input.
+	Index int
+	// Response is the response message. It is nil if the call failed.
+	Response proto.Message
+	// Status is the status of the call. Errors which aren't returned from the server are converted to a status.
+	Status *status.Status
+	// Elapsed is the latency of the call.
+	Elapsed time.Duration
+}
+
+// Batch calls the unary RPC once for each request read from the filler until the input reaches EOF.
+// Requests are read lazily, so the input may be larger than the memory. f is called with the result of each call in
+// the order the calls are finished. Calls of f are serialized.
+// Failures of calls are passed to f as well as successes. Batch stops if the input is malformed or f returns an
+// error, and returns the error after the in-flight calls are finished.
+// Headers, the default compressor and the recorder are applied as well as CallRPC.
+func Batch(ctx context.Context, rpcName string, opt *BatchOption, f func(*BatchResult) error) error {
+	return dm.Batch(ctx, rpcName, opt, f)
+}
+func (m *dependencyManager) Batch(ctx context.Context, rpcName string, opt *BatchOption, f func(*BatchResult) error) error {
+	fqsn := pb.FullyQualifiedServiceName(m.state.selectedPackage, m.state.selectedService)
+	d, err := m.descSource.FindSymbol(fmt.Sprintf("%s.%s", fqsn, rpcName))
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the RPC descriptor for: %s", rpcName)
+	}
+	rpc, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return errors.Errorf("'%s' is not a RPC", rpcName)
+	}
+	if rpc.IsStreamingClient() || rpc.IsStreamingServer() {
+		return errors.Errorf("'%s' is a streaming RPC, batch calls are available only for unary RPCs", rpcName)
+	}
+
+	opts, err := m.resolveCallOptions(opt.Timeout, "")
+	if err != nil {
+		return err
+	}
+	fqrn := string(rpc.FullName())
+	call := func(ctx context.Context, i int, req proto.Message) *BatchResult {
+		ctx, cancel := m.newOutgoingContext(ctx, nil, opts)
+		defer cancel()
+
+		rec := m.newRecording(fqrn)
+		start := time.Now()
+		md, _ := metadata.FromOutgoingContext(ctx)
+		rec.start(start, md)
+		rec.addRequest(req)
+
+		res := dynamicpb.NewMessage(rpc.Output())
+		header, trailer, err := m.gRPCClient.Invoke(ctx, fqrn, req, res)
+		elapsed := time.Since(start)
+		stat := errorStatus(err)
+
+		rec.setHeader(header)
+		if err == nil {
+			rec.addResponse(res)
+		}
+		rec.setTrailer(stat, trailer)
+		rec.finish(err)
+
+		result := &BatchResult{Index: i, Status: stat, Elapsed: elapsed}
+		if err == nil {
+			result.Response = res
+		}
+		return result
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type job struct {
+		i   int
+		req proto.Message
+	}
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		jobs = make(chan *job)
+		wg   sync.WaitGroup
+
+		mu     sync.Mutex
+		outErr error
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				res := call(ctx, j.i, j.req)
+
+				mu.Lock()
+				if outErr == nil {
+					if err := f(res); err != nil {
+						outErr = err
+						cancel()
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var inErr error
+read:
+	for i := 0; ; i++ {
+		req := dynamicpb.NewMessage(rpc.Input())
+		err := m.filler.Fill(req)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			inErr = errors.Wrapf(err, "failed to read the request #%d", i)
+			break
+		}
+		select {
+		case jobs <- &job{i: i, req: req}:
+		case <-ctx.Done():
+			break read
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if inErr != nil {
+		return inErr
+	}
+	if outErr != nil {
+		return outErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ktr0731/evans/fill"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestBatch(t *testing.T) {
+	hs, client := newHealthServer(t)
+	hs.SetServingStatus("api.Example", healthpb.HealthCheckResponse_SERVING)
+
+	type result struct {
+		Index int
+		Code  codes.Code
+		OK    bool
+	}
+	cases := map[string]struct {
+		rpcName string
+		in      string
+		f       func(*BatchResult) error
+
+		expected []result
+		hasErr   bool
+	}{
+		"each document is a call": {
+			rpcName: "Check",
+			in: `{"service": "api.Example"}
+{"service": "api.Unknown"}
+{}`,
+			expected: []result{
+				{Index: 0, Code: codes.OK, OK: true},
+				{Index: 1, Code: codes.NotFound},
+				{Index: 2, Code: codes.OK, OK: true},
+			},
+		},
+		"empty input": {
+			rpcName: "Check",
+		},
+		"malformed input": {
+			rpcName: "Check",
+			in:      `{"service": "api.Example"} {"service": `,
+			hasErr:  true,
+		},
+		"streaming RPC": {
+			rpcName: "Watch",
+			in:      `{}`,
+			hasErr:  true,
+		},
+		"f returns an error": {
+			rpcName: "Check",
+			in:      `{} {} {}`,
+			f:       func(*BatchResult) error { return errors.New("an error") },
+			hasErr:  true,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			defer Clear()
+			Inject(Dependencies{
+				GRPCClient: client,
+				DescSource: healthDescSource{},
+				Filler:     fill.NewSilentFiller(strings.NewReader(c.in)),
+			})
+			if err := UsePackage("grpc.health.v1"); err != nil {
+				t.Fatalf("UsePackage must not return an error, but got '%s'", err)
+			}
+			if err := UseService("Health"); err != nil {
+				t.Fatalf("UseService must not return an error, but got '%s'", err)
+			}
+
+			var actual []result
+			err := Batch(context.Background(), c.rpcName, &BatchOption{Concurrency: 2}, func(res *BatchResult) error {
+				actual = append(actual, result{Index: res.Index, Code: res.Status.Code(), OK: res.Response != nil})
+				if c.f != nil {
+					return c.f(res)
+				}
+				return nil
+			})
+			if c.hasErr {
+				if err == nil {
+					t.Errorf("Batch must return an error, but got nil")
+				}
+			} else if err != nil {
+				t.Fatalf("Batch must not return an error, but got '%s'", err)
+			}
+			if c.f != nil && len(actual) != 1 {
+				t.Errorf("Batch must stop after f returns an error, but f is called %d times", len(actual))
+			}
+			if c.hasErr {
+				return
+			}
+
+			sort.Slice(actual, func(i, j int) bool { return actual[i].Index < actual[j].Index })
+			if diff := cmp.Diff(c.expected, actual); diff != "" {
+				t.Errorf("-want, +got\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/ktr0731/evans/proto"
+	"github.com/pkg/errors"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// BenchOption is the option for Bench.
+type BenchOption struct {
+	// Concurrency is the number of workers that call the RPC concurrently. 1 if it is 0.
+	Concurrency int
+	// Total is the number of calls. If it is 0, Bench calls the RPC until Duration elapses.
+	Total int
+	// Duration is the duration of the benchmark. If it is 0, Bench calls the RPC Total times.
+	Duration time.Duration
+	// RPS is the maximum number of calls per second. No rate limit if it is 0.
+	RPS int
+	// Timeout is the timeout for each call. If it is 0, the default timeout is used.
+	Timeout time.Duration
+}
+
+// BenchResult is the result of Bench.
+type BenchResult struct {
+	// Elapsed is the time from the start of the first call to the end of the last call.
+	Elapsed time.Duration
+	// Latencies are the latencies of all calls in ascending order.
+	Latencies []time.Duration
+	// Codes is the number of calls for each status code.
+	Codes map[codes.Code]int
+}
+
+// Bench calls the RPC repeatedly with the requests read from the filler and measures the latencies.
+// All requests are read before the benchmark starts. Unary and server streaming RPCs use the first request, and
+// client and bidi streaming RPCs send all requests in each call. The responses are discarded.
+// Headers and the default compressor are applied as well as CallRPC.
+func Bench(ctx context.Context, rpcName string, opt *BenchOption) (*BenchResult, error) {
+	return dm.Bench(ctx, rpcName, opt)
+}
+func (m *dependencyManager) Bench(ctx context.Context, rpcName string, opt *BenchOption) (*BenchResult, error) {
+	if opt.Total == 0 && opt.Duration == 0 {
+		return nil, errors.New("either of the total number of calls or the duration is required")
+	}
+
+	fqsn := pb.FullyQualifiedServiceName(m.state.selectedPackage, m.state.selectedService)
+	d, err := m.descSource.FindSymbol(fmt.Sprintf("%s.%s", fqsn, rpcName))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the RPC descriptor for: %s", rpcName)
+	}
+	rpc, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, errors.Errorf("'%s' is not a RPC", rpcName)
+	}
+
+	var reqs []proto.Message
+	for {
+		req := dynamicpb.NewMessage(rpc.Input())
+		err := m.filler.Fill(req)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read a request")
+		}
+		reqs = append(reqs, req)
+		if !rpc.IsStreamingClient() {
+			break
+		}
+	}
+	if len(reqs) == 0 {
+		return nil, errors.New("one or more requests are required")
+	}
+
+	opts, err := m.resolveCallOptions(opt.Timeout, "")
+	if err != nil {
+		return nil, err
+	}
+	call := func() codes.Code {
+		ctx, cancel := m.newOutgoingContext(ctx, nil, opts)
+		defer cancel()
+		return status.Code(errors.Cause(m.invokeAll(ctx, rpc, reqs, nil)))
+	}
+
+	// jobs limits the number of calls and the rate.
+	jobs := make(chan struct{})
+	stopCtx := ctx
+	if opt.Duration > 0 {
+		var cancel context.CancelFunc
+		stopCtx, cancel = context.WithTimeout(ctx, opt.Duration)
+		defer cancel()
+	}
+	go func() {
+		defer close(jobs)
+		var tick <-chan time.Time
+		if opt.RPS > 0 {
+			// The interval is truncated to 0 if RPS is more than 1e9, but NewTicker requires a positive interval.
+			interval := time.Second / time.Duration(opt.RPS)
+			if interval <= 0 {
+				interval = time.Nanosecond
+			}
+			t := time.NewTicker(interval)
+			defer t.Stop()
+			tick = t.C
+		}
+		for i := 0; opt.Total == 0 || i < opt.Total; i++ {
+			if tick != nil {
+				select {
+				case <-tick:
+				case <-stopCtx.Done():
+					return
+				}
+			}
+			select {
+			case jobs <- struct{}{}:
+			case <-stopCtx.Done():
+				return
+			}
+		}
+	}()
+
+	concurrency := opt.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	var (
+		mu  sync.Mutex
+		res = &BenchResult{Codes: make(map[codes.Code]int)}
+		wg  sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				s := time.Now()
+				code := call()
+				latency := time.Since(s)
+
+				mu.Lock()
+				res.Latencies = append(res.Latencies, latency)
+				res.Codes[code]++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	res.Elapsed = time.Since(start)
+
+	sort.Slice(res.Latencies, func(i, j int) bool { return res.Latencies[i] < res.Latencies[j] })
+	return res, nil
+}
+
+// invokeAll calls the RPC with reqs. onResponse is called with each response if it is not nil.
+// Unary and server streaming RPCs send only the first request.
+func (m *dependencyManager) invokeAll(ctx context.Context, rpc protoreflect.MethodDescriptor, reqs []proto.Message, onResponse func(proto.Message)) error {
+	fqrn := string(rpc.FullName())
+	streamDesc := &gogrpc.StreamDesc{
+		StreamName:    string(rpc.Name()),
+		ServerStreams: rpc.IsStreamingServer(),
+		ClientStreams: rpc.IsStreamingClient(),
+	}
+	newResponse := func() proto.Message { return dynamicpb.NewMessage(rpc.Output()) }
+	handleResponse := func(res proto.Message) {
+		if onResponse != nil {
+			onResponse(res)
+		}
+	}
+	receiveAll := func(stream interface{ Receive(res interface{}) error }) error {
+		for {
+			res := newResponse()
+			if err := stream.Receive(res); errors.Is(err, io.EOF) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			handleResponse(res)
+		}
+	}
+
+	switch {
+	case rpc.IsStreamingClient() && rpc.IsStreamingServer():
+		stream, err := m.gRPCClient.NewBidiStream(ctx, streamDesc, fqrn)
+		if err != nil {
+			return err
+		}
+		for _, req := range reqs {
+			if err := stream.Send(req); err != nil {
+				return err
+			}
+		}
+		if err := stream.CloseSend(); err != nil {
+			return err
+		}
+		return receiveAll(stream)
+	case rpc.IsStreamingClient():
+		stream, err := m.gRPCClient.NewClientStream(ctx, streamDesc, fqrn)
+		if err != nil {
+			return err
+		}
+		for _, req := range reqs {
+			if err := stream.Send(req); err != nil {
+				return err
+			}
+		}
+		res := newResponse()
+		if err := stream.CloseAndReceive(res); err != nil {
+			return err
+		}
+		handleResponse(res)
+		return nil
+	case rpc.IsStreamingServer():
+		stream, err := m.gRPCClient.NewServerStream(ctx, streamDesc, fqrn)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(reqs[0]); err != nil {
+			return err
+		}
+		return receiveAll(stream)
+	default:
+		res := newResponse()
+		if _, _, err := m.gRPCClient.Invoke(ctx, fqrn, reqs[0], res); err != nil {
+			return err
+		}
+		handleResponse(res)
+		return nil
+	}
+}
+
+// BenchReport is the summary of BenchResult.
+type BenchReport struct {
+	Count      int
+	Elapsed    time.Duration
+	Throughput float64 // Calls per second.
+
+	Fastest, Average, Slowest time.Duration
+	P50, P90, P99             time.Duration
+
+	// Codes is the number of calls for each status code.
+	Codes map[codes.Code]int
+	// Histogram is the latency histogram which has evenly spaced buckets from Fastest to Slowest.
+	Histogram []BenchBucket
+}
+
+// BenchBucket is a bucket of the latency histogram.
+type BenchBucket struct {
+	// Latency is the upper bound of the bucket.
+	Latency time.Duration
+	Count   int
+}
+
+// histogramBuckets is the number of buckets of the latency histogram.
+const histogramBuckets = 10
+
+// Report summarizes the result.
+func (r *BenchResult) Report() *BenchReport {
+	report := &BenchReport{
+		Count:   len(r.Latencies),
+		Elapsed: r.Elapsed,
+		Codes:   r.Codes,
+	}
+	if report.Count == 0 {
+		return report
+	}
+	if r.Elapsed > 0 {
+		report.Throughput = float64(report.Count) / r.Elapsed.Seconds()
+	}
+
+	var sum time.Duration
+	for _, l := range r.Latencies {
+		sum += l
+	}
+	percentile := func(p int) time.Duration {
+		i := (report.Count*p+99)/100 - 1
+		return r.Latencies[i]
+	}
+	report.Fastest, report.Slowest = r.Latencies[0], r.Latencies[report.Count-1]
+	report.Average = sum / time.Duration(report.Count)
+	report.P50, report.P90, report.P99 = percentile(50), percentile(90), percentile(99)
+
+	width := (report.Slowest - report.Fastest) / histogramBuckets
+	report.Histogram = make([]BenchBucket, histogramBuckets)
+	for i := range report.Histogram {
+		report.Histogram[i].Latency = report.Fastest + width*time.Duration(i+1)
+	}
+	report.Histogram[histogramBuckets-1].Latency = report.Slowest
+	var i int
+	for _, l := range r.Latencies {
+		for l > report.Histogram[i].Latency {
+			i++
+		}
+		report.Histogram[i].Count++
+	}
+	return report
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ktr0731/evans/fill"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type healthDescSource struct{}
+
+func (healthDescSource) ListServices() ([]string, error) { return []string{healthServiceName}, nil }
+
+func (healthDescSource) FindSymbol(name string) (protoreflect.Descriptor, error) {
+	d := healthpb.File_grpc_health_v1_health_proto.Services().ByName("Health")
+	if m := d.Methods().ByName(protoreflect.Name(strings.TrimPrefix(name, healthServiceName+"."))); m != nil {
+		return m, nil
+	}
+	return nil, ErrUnknownSymbol
+}
+
+func TestBench(t *testing.T) {
+	hs, client := newHealthServer(t)
+	hs.SetServingStatus("api.Example", healthpb.HealthCheckResponse_SERVING)
+
+	cases := map[string]struct {
+		rpcName string
+		in      string
+		opt     *BenchOption
+
+		expectedCodes map[codes.Code]int
+		hasErr        bool
+	}{
+		"unary": {
+			rpcName:       "Check",
+			in:            `{"service": "api.Example"}`,
+			opt:           &BenchOption{Concurrency: 4, Total: 20},
+			expectedCodes: map[codes.Code]int{codes.OK: 20},
+		},
+		"unary with errors": {
+			rpcName:       "Check",
+			in:            `{"service": "api.Unknown"}`,
+			opt:           &BenchOption{Concurrency: 2, Total: 5},
+			expectedCodes: map[codes.Code]int{codes.NotFound: 5},
+		},
+		"server streaming with a timeout": {
+			rpcName:       "Watch",
+			in:            `{"service": "api.Example"}`,
+			opt:           &BenchOption{Concurrency: 2, Total: 4, Timeout: 50 * time.Millisecond},
+			expectedCodes: map[codes.Code]int{codes.DeadlineExceeded: 4},
+		},
+		"rate limited": {
+			rpcName:       "Check",
+			in:            `{}`,
+			opt:           &BenchOption{Concurrency: 2, Total: 3, RPS: 100},
+			expectedCodes: map[codes.Code]int{codes.OK: 3},
+		},
+		"rps more than 1e9": {
+			rpcName:       "Check",
+			in:            `{}`,
+			opt:           &BenchOption{Concurrency: 2, Total: 3, RPS: 2e9},
+			expectedCodes: map[codes.Code]int{codes.OK: 3},
+		},
+		"no requests": {
+			rpcName: "Check",
+			opt:     &BenchOption{Total: 1},
+			hasErr:  true,
+		},
+		"neither total nor duration": {
+			rpcName: "Check",
+			in:      `{}`,
+			opt:     &BenchOption{},
+			hasErr:  true,
+		},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			defer Clear()
+			Inject(Dependencies{
+				GRPCClient: client,
+				DescSource: healthDescSource{},
+				Filler:     fill.NewSilentFiller(strings.NewReader(c.in)),
+			})
+			if err := UsePackage("grpc.health.v1"); err != nil {
+				t.Fatalf("UsePackage must not return an error, but got '%s'", err)
+			}
+			if err := UseService("Health"); err != nil {
+				t.Fatalf("UseService must not return an error, but got '%s'", err)
+			}
+
+			res, err := Bench(context.Background(), c.rpcName, c.opt)
+			if c.hasErr {
+				if err == nil {
+					t.Errorf("Bench must return an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Bench must not return an error, but got '%s'", err)
+			}
+			if diff := cmp.Diff(c.expectedCodes, res.Codes); diff != "" {
+				t.Errorf("-want, +got\n%s", diff)
+			}
+			if len(res.Latencies) != c.opt.Total {
+				t.Errorf("expected %d latencies, but got %d", c.opt.Total, len(res.Latencies))
+			}
+		})
+	}
+}
+
+func TestBench_duration(t *testing.T) {
+	_, client := newHealthServer(t)
+
+	defer Clear()
+	Inject(Dependencies{
+		GRPCClient: client,
+		DescSource: healthDescSource{},
+		Filler:     fill.NewSilentFiller(strings.NewReader(`{}`)),
+	})
+	if err := UsePackage("grpc.health.v1"); err != nil {
+		t.Fatalf("UsePackage must not return an error, but got '%s'", err)
+	}
+	if err := UseService("Health"); err != nil {
+		t.Fatalf("UseService must not return an error, but got '%s'", err)
+	}
+
+	res, err := Bench(context.Background(), "Check", &BenchOption{Concurrency: 2, Duration: 100 * time.Millisecond, RPS: 50})
+	if err != nil {
+		t.Fatalf("Bench must not return an error, but got '%s'", err)
+	}
+	// 50 RPS in 100ms is about 5 calls.
+	if n := res.Codes[codes.OK]; n == 0 || n > 6 {
+		t.Errorf("the number of calls must be limited by the duration and the rate, but got %d", n)
+	}
+	if res.Elapsed < 100*time.Millisecond {
+		t.Errorf("the benchmark must run for the duration, but got %s", res.Elapsed)
+	}
+}
+
+func TestBenchResult_Report(t *testing.T) {
+	res := &BenchResult{
+		Elapsed: 2 * time.Second,
+		Codes:   map[codes.Code]int{codes.OK: 9, codes.Internal: 1},
+	}
+	for i := 1; i <= 10; i++ {
+		res.Latencies = append(res.Latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	report := res.Report()
+	if report.Count != 10 || report.Throughput != 5 {
+		t.Errorf("unexpected count or throughput: %d, %f", report.Count, report.Throughput)
+	}
+	if report.Fastest != time.Millisecond || report.Slowest != 10*time.Millisecond || report.Average != 5500*time.Microsecond {
+		t.Errorf("unexpected fastest, slowest or average: %s, %s, %s", report.Fastest, report.Slowest, report.Average)
+	}
+	if report.P50 != 5*time.Millisecond || report.P90 != 9*time.Millisecond || report.P99 != 10*time.Millisecond {
+		t.Errorf("unexpected percentiles: %s, %s, %s", report.P50, report.P90, report.P99)
+	}
+	var n int
+	for _, b := range report.Histogram {
+		n += b.Count
+	}
+	if len(report.Histogram) != histogramBuckets || n != 10 {
+		t.Errorf("the histogram must have %d buckets and 10 calls, but got %d buckets and %d calls", histogramBuckets, len(report.Histogram), n)
+	}
+	if last := report.Histogram[len(report.Histogram)-1]; last.Latency != report.Slowest {
+		t.Errorf("the last bucket must be the slowest latency, but got %s", last.Latency)
+	}
+
+	if report := (&BenchResult{}).Report(); report.Count != 0 || report.Histogram != nil {
+		t.Errorf("the report of the empty result must be empty, but got %+v", report)
+	}
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ktr0731/evans/fill"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// maxCallHistorySize is the number of calls kept in the history. Older calls are removed.
+const maxCallHistorySize = 100
+
+// callHistoryEntry is a call in the history.
+type callHistoryEntry struct {
+	id        int
+	method    string
+	startedAt time.Time
+	elapsed   time.Duration
+	status    *status.Status
+	requests  []*callRequest
+}
+
+// addCallHistory adds the call of method to the history. Calls which failed before the start are not added.
+func (m *dependencyManager) addCallHistory(method protoreflect.MethodDescriptor, start time.Time, reqs []*callRequest, err error) {
+	if start.IsZero() {
+		return
+	}
+	m.state.callHistorySeq++
+	m.state.callHistory = append(m.state.callHistory, &callHistoryEntry{
+		id:        m.state.callHistorySeq,
+		method:    string(method.FullName()),
+		startedAt: start,
+		elapsed:   time.Since(start),
+		status:    errorStatus(err),
+		requests:  reqs,
+	})
+	if n := len(m.state.callHistory); n > maxCallHistorySize {
+		m.state.callHistory = m.state.callHistory[n-maxCallHistorySize:]
+	}
+}
+
+func (m *dependencyManager) findCallHistory(id int) (*callHistoryEntry, error) {
+	for _, e := range m.state.callHistory {
+		if e.id == id {
+			return e, nil
+		}
+	}
+	return nil, errors.Errorf("call #%d is not found in the history", id)
+}
+
+// FormatCallHistory formats the last n calls in the history. Each call is numbered from 1 in the order it was called.
+func FormatCallHistory(n int) (string, error) {
+	return dm.FormatCallHistory(n)
+}
+func (m *dependencyManager) FormatCallHistory(n int) (string, error) {
+	type call struct {
+		ID        int    `json:"id" table:"#"`
+		Method    string `json:"method" table:"method"`
+		StartedAt string `json:"startedAt" table:"started at"`
+		Status    string `json:"status" table:"status"`
+		Latency   string `json:"latency" table:"latency"`
+	}
+	var v struct {
+		Calls []call `json:"calls"`
+	}
+	history := m.state.callHistory
+	if n > 0 && len(history) > n {
+		history = history[len(history)-n:]
+	}
+	for _, e := range history {
+		v.Calls = append(v.Calls, call{
+			ID:        e.id,
+			Method:    e.method,
+			StartedAt: e.startedAt.Format(time.RFC3339),
+			Status:    e.status.Code().String(),
+			Latency:   e.elapsed.Round(time.Microsecond).String(),
+		})
+	}
+	out, err := m.resourcePresenter.Format(v)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to format the call history by presenter")
+	}
+	return out, nil
+}
+
+// FormatCallHistoryRequests formats the requests of the call id in the history as JSON.
+// Each message of client and bidi streaming RPCs is formatted as a JSON object in the order it was sent.
+func FormatCallHistoryRequests(id int) (string, error) {
+	return dm.FormatCallHistoryRequests(id)
+}
+func (m *dependencyManager) FormatCallHistoryRequests(id int) (string, error) {
+	e, err := m.findCallHistory(id)
+	if err != nil {
+		return "", err
+	}
+	rpc, err := m.findMethod(e.method)
+	if err != nil {
+		return "", err
+	}
+	msgs := make([]string, 0, len(e.requests))
+	for _, r := range e.requests {
+		req := dynamicpb.NewMessage(rpc.Input())
+		if err := proto.Unmarshal(r.payload, req); err != nil {
+			return "", errors.Wrapf(err, "failed to unmarshal the request of call #%d", id)
+		}
+		b, err := protojson.Marshal(req)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to format the request of call #%d", id)
+		}
+		msg, err := indentJSON(b)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to format the request of call #%d", id)
+		}
+		msgs = append(msgs, msg)
+	}
+	return strings.Join(msgs, "\n") + "\n", nil
+}
+
+// ResendCall calls the method of the call id in the history again. If in is nil, the same requests are resent.
+// Otherwise, the requests are read from in as JSON instead. The call is added to the history as a new call.
+func ResendCall(ctx context.Context, w io.Writer, id int, in io.Reader) error {
+	return dm.ResendCall(ctx, w, id, in)
+}
+func (m *dependencyManager) ResendCall(ctx context.Context, w io.Writer, id int, in io.Reader) error {
+	e, err := m.findCallHistory(id)
+	if err != nil {
+		return err
+	}
+	var filler fill.Filler = &payloadFiller{reqs: e.requests}
+	if in != nil {
+		filler = fill.NewSilentFiller(in)
+	}
+	return m.CallRPC(ctx, w, e.method, false, false, filler, 0, "")
+}
+
+// findMethod returns the method descriptor of the fully-qualified method name.
+func (m *dependencyManager) findMethod(fqmn string) (protoreflect.MethodDescriptor, error) {
+	d, err := m.descSource.FindSymbol(fqmn)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the RPC descriptor for: %s", fqmn)
+	}
+	rpc, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, errors.Errorf("'%s' is not a RPC", fqmn)
+	}
+	return rpc, nil
+}
+
+// payloadFiller is a fill.Filler implementation that fills the serialized requests in order.
+type payloadFiller struct {
+	reqs []*callRequest
+}
+
+func (f *payloadFiller) Fill(v *dynamicpb.Message) error {
+	if len(f.reqs) == 0 {
+		return io.EOF
+	}
+	r := f.reqs[0]
+	f.reqs = f.reqs[1:]
+	if err := proto.Unmarshal(r.payload, v); err != nil {
+		return errors.Wrap(err, "failed to unmarshal the request")
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddCallHistory(t *testing.T) {
+	d := &dependencyManager{}
+	method := getStubMethod(false)
+
+	d.addCallHistory(method, time.Time{}, nil, nil)
+	if n := len(d.state.callHistory); n != 0 {
+		t.Fatalf("calls which failed before the start must not be added, but got %d calls", n)
+	}
+
+	for i := 0; i < maxCallHistorySize+1; i++ {
+		d.addCallHistory(method, time.Now(), []*callRequest{{payload: []byte("foo")}}, nil)
+	}
+	if n := len(d.state.callHistory); n != maxCallHistorySize {
+		t.Errorf("expected %d calls, but got %d", maxCallHistorySize, n)
+	}
+	if _, err := d.findCallHistory(1); err == nil {
+		t.Errorf("the oldest call must be removed, but it was found")
+	}
+	e, err := d.findCallHistory(maxCallHistorySize + 1)
+	if err != nil {
+		t.Fatalf("should not return an error, but got '%s'", err)
+	}
+	if e.method != "TestRPC" {
+		t.Errorf("expected method 'TestRPC', but got '%s'", e.method)
+	}
+}
@@ -11,12 +11,15 @@ import (
 	pb "github.com/ktr0731/evans/proto"
 
 	"github.com/ktr0731/evans/fill"
+	"github.com/ktr0731/evans/grpc"
 	"github.com/ktr0731/evans/logger"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 	gogrpc "google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
@@ -47,54 +50,185 @@ func (e *gRPCError) Code() ErrorCode {
 	return ErrorCode(e.Status.Code())
 }
 
+// SetDefaultTimeout sets the timeout used for RPCs called without a timeout. No timeout if d is 0.
+func SetDefaultTimeout(d time.Duration) {
+	dm.SetDefaultTimeout(d)
+}
+func (m *dependencyManager) SetDefaultTimeout(d time.Duration) {
+	m.state.defaultTimeout = d
+}
+
+// SetDefaultCompression sets the compressor used for RPCs called without a compressor. No compression if name is empty.
+func SetDefaultCompression(name string) {
+	dm.SetDefaultCompression(name)
+}
+func (m *dependencyManager) SetDefaultCompression(name string) {
+	m.state.defaultCompression = name
+}
+
+// callOptions are the options of a call which are applied to the outgoing context by newOutgoingContext.
+type callOptions struct {
+	// timeout is the timeout of the call. It is used only if hasDeadline is true.
+	timeout     time.Duration
+	hasDeadline bool
+	// compression is the name of the compressor. No compression if it is empty.
+	compression string
+}
+
+// resolveCallOptions returns the options of a call. timeout must not be negative. If timeout is 0, the default
+// timeout is used. If compression is empty, the default compressor is used.
+// For backward compatibility, the grpc-timeout header is also available to set the deadline if there is no timeout.
+func (m *dependencyManager) resolveCallOptions(timeout time.Duration, compression string) (*callOptions, error) {
+	if timeout < 0 {
+		return nil, errors.Errorf("timeout must be a non-negative duration, but got %s", timeout)
+	}
+	if timeout == 0 {
+		timeout = m.state.defaultTimeout
+	}
+	if compression == "" {
+		compression = m.state.defaultCompression
+	}
+	if compression != "" && encoding.GetCompressor(compression) == nil {
+		return nil, errors.Errorf("unknown compressor '%s', available compressors are gzip, zstd and snappy", compression)
+	}
+
+	opts := &callOptions{timeout: timeout, hasDeadline: timeout > 0, compression: compression}
+	if values := m.ListHeaders()["grpc-timeout"]; !opts.hasDeadline && len(values) != 0 {
+		replacer := strings.NewReplacer("n", "ns", "u", "us", "m", "ms", "S", "s", "M", "m", "H", "h")
+		timeout, err := time.ParseDuration(replacer.Replace(values[len(values)-1]))
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed grpc-timeout header")
+		}
+		opts.timeout, opts.hasDeadline = timeout, true
+	}
+	return opts, nil
+}
+
+// newOutgoingContext returns a new context for a call. It has header and the headers added by AddHeader, the compressor
+// and the deadline of opts. The headers added by AddHeader have priority.
+// The grpc-timeout header is not sent as it is because the deadline is propagated by the transport.
+func (m *dependencyManager) newOutgoingContext(ctx context.Context, header metadata.MD, opts *callOptions) (context.Context, context.CancelFunc) {
+	md := header.Copy()
+	if md == nil {
+		md = metadata.MD{}
+	}
+	for k, v := range m.ListHeaders() {
+		md.Set(k, v...)
+	}
+	md.Delete("grpc-timeout")
+
+	ctx = metadata.NewOutgoingContext(ctx, md)
+	if opts.compression != "" {
+		ctx = grpc.NewContextWithCompressor(ctx, opts.compression)
+	}
+	if opts.hasDeadline {
+		return context.WithTimeout(ctx, opts.timeout)
+	}
+	return ctx, func() {}
+}
+
 // CallRPC constructs a request with input source such that prompt inputting, stdin or a file. After that, it sends
 // the request to the gRPC server and decodes the response body to res.
 // Note that req and res must be JSON-decodable structs. The output is written to w.
 func CallRPC(ctx context.Context, w io.Writer, rpcName string) error {
-	return dm.CallRPC(ctx, w, rpcName, false, dm.filler)
+	return dm.CallRPC(ctx, w, rpcName, false, false, dm.filler, 0, "")
 }
-func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName string, rerunPrevious bool, filler fill.Filler) error {
-	fqsn := pb.FullyQualifiedServiceName(m.state.selectedPackage, m.state.selectedService)
-	d, err := m.descSource.FindSymbol(fmt.Sprintf("%s.%s", fqsn, rpcName))
+
+// CallRPC calls the RPC. If timeout is 0, the default timeout is used.
+// If compression is empty, the default compressor is used.
+// If rerunPrevious is true, the requests of the previous call are resent instead of filling new ones. If keepTiming is
+// also true, the messages of client and bidi streaming RPCs are resent at the same intervals as the previous call.
+func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName string, rerunPrevious, keepTiming bool, filler fill.Filler, timeout time.Duration, compression string) (err error) {
+	rpc, err := m.findRPC(rpcName)
 	if err != nil {
-		return errors.Wrapf(err, "failed to get the RPC descriptor for: %s", rpcName)
+		return err
 	}
 
-	rpc := d.(protoreflect.MethodDescriptor) // TODO: handle "ok".
-
-	if rerunPrevious && rpc.IsStreamingClient() {
-		return errors.New("cannot rerun previous RPC as client/bidi streaming RPCs are not supported")
+	var (
+		// start is the time when the RPC started. It is used to report the elapsed time if the deadline is exceeded.
+		start time.Time
+		// sent are the requests of this call. The call state is updated each time a request is filled.
+		sent []*callRequest
+	)
+	rec := m.newRecording(string(rpc.FullName()))
+	defer func() {
+		rec.finish(err)
+		m.addCallHistory(rpc, start, sent, err)
+	}()
+
+	var (
+		// previous are the requests of the previous call which are resent if rerunPrevious is true.
+		previous []*callRequest
+		lastSent time.Time
+	)
+	if rerunPrevious {
+		previous, err = m.getPreviousRPCRequests(rpc)
+		if err != nil {
+			return err
+		}
 	}
 	newRequest := func() (*dynamicpb.Message, error) {
 		req := dynamicpb.NewMessage(rpc.Input())
 		if !rerunPrevious {
-			err = filler.Fill(req)
+			err := filler.Fill(req)
 			if errors.Is(err, io.EOF) {
 				return nil, io.EOF
 			}
 			if err != nil {
 				return nil, err
 			}
-			if err := m.updateMethodCallState(rpcName, req); err != nil {
+			now := time.Now()
+			var interval time.Duration
+			if !lastSent.IsZero() {
+				interval = now.Sub(lastSent)
+			}
+			lastSent = now
+			b, err := proto.Marshal(req)
+			if err != nil {
 				return nil, err
 			}
+			sent = append(sent, &callRequest{payload: b, interval: interval})
+			m.updateMethodCallState(rpc, sent)
+			rec.addRequest(req)
 			return req, nil
 		}
-		if err = m.getPreviousRPCRequest(rpc, req); err != nil {
-			return nil, err
+		if len(previous) == 0 {
+			return nil, io.EOF
+		}
+		next := previous[0]
+		previous = previous[1:]
+		if keepTiming && next.interval > 0 {
+			select {
+			case <-time.After(next.interval):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if err := proto.Unmarshal(next.payload, req); err != nil { // TODO: Custom resolver.
+			return nil, errors.Wrapf(err, "error while unmarshalling request for method: %s, please run without the --repeat option", rpc.FullName())
 		}
+		sent = append(sent, next)
+		rec.addRequest(req)
 		return req, nil
 	}
 	newResponse := func() interface{} {
 		return dynamicpb.NewMessage(rpc.Output())
 	}
+	// p is the peer which served the RPC. It is set by the gRPC client.
+	var p peer.Peer
 	flushHeader := func(header metadata.MD) {
+		if p.Addr != nil {
+			m.responseFormatter.FormatPeer(&p)
+		}
 		m.responseFormatter.FormatHeader(header)
+		rec.setHeader(header)
 	}
 	flushResponse := func(res interface{}) error {
+		rec.addResponse(res)
 		return m.responseFormatter.FormatMessage(res)
 	}
 	flushTrailer := func(status *status.Status, trailer metadata.MD) error {
+		rec.setTrailer(status, trailer)
 		return m.responseFormatter.FormatTrailer(status, trailer)
 	}
 	flushDone := func() error {
@@ -111,36 +245,40 @@ func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName st
 		return flushDone()
 	}
 
-	parseDuration := func(duration string) (time.Duration, error) {
-		replacer := strings.NewReplacer("n", "ns", "u", "us", "m", "ms", "S", "s", "M", "m", "H", "h")
-		duration = replacer.Replace(duration)
-		timeout, err := time.ParseDuration(duration)
-		if err != nil {
-			return 0, errors.Wrapf(err, "malformed grpc-timeout header")
-		}
-		return timeout, err
+	opts, err := m.resolveCallOptions(timeout, compression)
+	if err != nil {
+		return err
 	}
 
-	enhanceContext := func(ctx context.Context) (context.Context, context.CancelFunc, error) {
-		md := metadata.New(nil)
-		for k, v := range m.ListHeaders() {
-			md.Append(k, v...)
-		}
+	enhanceContext := func(ctx context.Context) (context.Context, context.CancelFunc) {
+		ctx, cancel := m.newOutgoingContext(ctx, nil, opts)
+		ctx = grpc.NewContextWithPeer(ctx, &p)
+		start = time.Now()
+		md, _ := metadata.FromOutgoingContext(ctx)
+		rec.start(start, md)
+		return ctx, cancel
+	}
 
-		ctx = metadata.NewOutgoingContext(ctx, md)
-		values := md.Get("grpc-timeout")
-		if len(values) == 0 {
-			return ctx, func() {}, nil
+	// deadlineExceeded returns a DeadlineExceeded status describing the elapsed time if ctx exceeded the deadline.
+	// Otherwise, it returns nil.
+	deadlineExceeded := func(ctx context.Context) *status.Status {
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil
 		}
+		elapsed := time.Since(start).Round(time.Millisecond)
+		return status.Newf(codes.DeadlineExceeded, "deadline exceeded: the RPC didn't finish within %s (elapsed: %s)", opts.timeout, elapsed)
+	}
 
-		timeout, err := parseDuration(values[len(values)-1])
-		if err != nil {
-			return nil, func() {}, err
+	// handleResponseError is the same as handleGRPCResponseError, but it converts the error to the status returned
+	// from deadlineExceeded if ctx exceeded the deadline.
+	handleResponseError := func(ctx context.Context, err error) (*status.Status, error) {
+		stat, err := handleGRPCResponseError(err)
+		if (err != nil && !errors.Is(err, io.EOF)) || stat.Code() == codes.DeadlineExceeded {
+			if stat := deadlineExceeded(ctx); stat != nil {
+				return stat, nil
+			}
 		}
-
-		ctx, cancel := context.WithTimeout(ctx, timeout)
-
-		return ctx, cancel, nil
+		return stat, err
 	}
 
 	streamDesc := &gogrpc.StreamDesc{
@@ -151,15 +289,14 @@ func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName st
 
 	switch {
 	case rpc.IsStreamingClient() && rpc.IsStreamingServer():
-		ctx, cancel, err := enhanceContext(ctx)
-		if err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to enhance context with metadata")
-		}
+		ctx, cancel := enhanceContext(ctx)
+		defer cancel()
 
 		stream, err := m.gRPCClient.NewBidiStream(ctx, streamDesc, string(rpc.FullName()))
 		if err != nil {
-			cancel()
+			if stat := deadlineExceeded(ctx); stat != nil {
+				return &gRPCError{stat}
+			}
 			return errors.Wrapf(err, "failed to create a bidi stream for RPC '%s'", streamDesc.StreamName)
 		}
 
@@ -170,7 +307,7 @@ func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName st
 		eg.Go(func() error {
 			for {
 				res := newResponse()
-				stat, err := handleGRPCResponseError(stream.Receive(res))
+				stat, err := handleResponseError(ctx, stream.Receive(res))
 				if err != nil {
 					if errors.Is(err, context.Canceled) {
 						return nil
@@ -262,15 +399,14 @@ func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName st
 	//   6. Format the response and output it.
 	//
 	case rpc.IsStreamingClient():
-		ctx, cancel, err := enhanceContext(ctx)
-		if err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to enhance context with metadata")
-		}
+		ctx, cancel := enhanceContext(ctx)
+		defer cancel()
 
 		stream, err := m.gRPCClient.NewClientStream(ctx, streamDesc, string(rpc.FullName()))
 		if err != nil {
-			cancel()
+			if stat := deadlineExceeded(ctx); stat != nil {
+				return &gRPCError{stat}
+			}
 			return errors.Wrapf(err, "failed to create a new client stream for RPC '%s'", streamDesc.StreamName)
 		}
 
@@ -279,7 +415,7 @@ func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName st
 
 			if errors.Is(err, io.EOF) {
 				res := newResponse()
-				stat, err := handleGRPCResponseError(stream.CloseAndReceive(res))
+				stat, err := handleResponseError(ctx, stream.CloseAndReceive(res))
 				if err != nil {
 					return errors.Wrapf(err, "failed to close the stream of RPC '%s'", streamDesc.StreamName)
 				}
@@ -309,6 +445,9 @@ func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName st
 				return err
 			}
 			if err := stream.Send(req); err != nil {
+				if stat := deadlineExceeded(ctx); stat != nil {
+					return &gRPCError{stat}
+				}
 				return errors.Wrapf(err, "failed to send a RPC to the client stream '%s'", streamDesc.StreamName)
 			}
 		}
@@ -328,19 +467,21 @@ func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName st
 			return err
 		}
 
-		ctx, cancel, err := enhanceContext(ctx)
-		if err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to enhance context with metadata")
-		}
+		ctx, cancel := enhanceContext(ctx)
+		defer cancel()
 
 		stream, err := m.gRPCClient.NewServerStream(ctx, streamDesc, string(rpc.FullName()))
 		if err != nil {
-			cancel()
+			if stat := deadlineExceeded(ctx); stat != nil {
+				return &gRPCError{stat}
+			}
 			return errors.Wrapf(err, "failed to create a new server stream for RPC '%s'", streamDesc.StreamName)
 		}
 
 		if err := stream.Send(req); err != nil {
+			if stat := deadlineExceeded(ctx); stat != nil {
+				return &gRPCError{stat}
+			}
 			return errors.Wrapf(err, "failed to send a RPC to the server stream '%s'", streamDesc.StreamName)
 		}
 
@@ -348,7 +489,7 @@ func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName st
 
 		for {
 			res := newResponse()
-			stat, err := handleGRPCResponseError(stream.Receive(res))
+			stat, err := handleResponseError(ctx, stream.Receive(res))
 			if err != nil {
 				if errors.Is(err, context.Canceled) {
 					return nil
@@ -406,17 +547,13 @@ func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName st
 			return err
 		}
 
-		ctx, cancel, err := enhanceContext(ctx)
-		if err != nil {
-			cancel()
-			return errors.Wrap(err, "failed to enhance context with metadata")
-		}
+		ctx, cancel := enhanceContext(ctx)
+		defer cancel()
 
 		res := newResponse()
 		header, trailer, err := m.gRPCClient.Invoke(ctx, string(rpc.FullName()), req, res)
-		stat, err := handleGRPCResponseError(err)
+		stat, err := handleResponseError(ctx, err)
 		if err != nil {
-			cancel()
 			return errors.Wrap(err, "failed to send a request")
 		}
 
@@ -436,42 +573,40 @@ func (m *dependencyManager) CallRPC(ctx context.Context, w io.Writer, rpcName st
 	}
 }
 
-// Gets a request with the body containing the payload of its previous method
-// Only RPCs that are repeatable by definition will have their previous requests returned.
-func (m *dependencyManager) getPreviousRPCRequest(method protoreflect.MethodDescriptor, req proto.Message) error {
+// Gets the requests of the previous call of the method in the order they were sent.
+// Unary and server streaming RPCs have only one request, and client and bidi streaming RPCs have all messages sent on
+// the stream.
+func (m *dependencyManager) getPreviousRPCRequests(method protoreflect.MethodDescriptor) ([]*callRequest, error) {
 	id := rpcIdentifier(string(method.FullName()))
 	if _, ok := m.state.rpcCallState[id]; !ok {
-		return errors.Errorf("no previous request exists for method: %s, please issue a normal request", id)
-	}
-	if method.IsStreamingClient() {
-		return errors.Errorf("cannot rerun previous method: %s as client/bidi streaming RPCs are not supported", id)
+		return nil, errors.Errorf("no previous request exists for method: %s, please issue a normal request", id)
 	}
-	previousReqBytes := m.state.rpcCallState[id].requestPayload
-	if previousReqBytes == nil {
-		return errors.Errorf("no previous request body exists for method: %s, please issue a normal request", id)
+	reqs := m.state.rpcCallState[id].requests
+	if len(reqs) == 0 {
+		return nil, errors.Errorf("no previous request body exists for method: %s, please issue a normal request", id)
 	}
-	err := proto.Unmarshal(previousReqBytes, req) // TODO: Custom resolver.
-	if err != nil {
-		return errors.Wrapf(err, "error while unmarshalling request for method: %s, please run without the --repeat option", id)
-	}
-	return nil
+	return reqs, nil
 }
 
-// Updates the last call state for the given method. This is done by serializing
-// the request payload and store it into the state buffer indexed by the rpcName
-// The method is repeatable only if it is not a client streaming method.
-func (m *dependencyManager) updateMethodCallState(rpcName string, req *dynamicpb.Message) error {
-	reqBytes, err := proto.Marshal(req)
-	if err != nil {
-		return err
-	}
+// Updates the last call state for the given method. reqs are the serialized requests sent in the call so far, and
+// they are stored into the state buffer indexed by the fully-qualified method name.
+func (m *dependencyManager) updateMethodCallState(method protoreflect.MethodDescriptor, reqs []*callRequest) {
 	if m.state.rpcCallState == nil {
 		m.state.rpcCallState = make(map[rpcIdentifier]callState)
 	}
-	m.state.rpcCallState[rpcIdentifier(rpcName)] = callState{
-		requestPayload: reqBytes,
+	m.state.rpcCallState[rpcIdentifier(string(method.FullName()))] = callState{
+		requests: append([]*callRequest(nil), reqs...),
 	}
-	return nil
+}
+
+// findRPC returns the method descriptor of rpcName. rpcName is a method of the selected service, or a
+// fully-qualified method name such as calls resent from the history.
+func (m *dependencyManager) findRPC(rpcName string) (protoreflect.MethodDescriptor, error) {
+	if strings.Contains(rpcName, ".") {
+		return m.findMethod(rpcName)
+	}
+	fqsn := pb.FullyQualifiedServiceName(m.state.selectedPackage, m.state.selectedService)
+	return m.findMethod(fmt.Sprintf("%s.%s", fqsn, rpcName))
 }
 
 type interactiveFiller struct {
@@ -482,12 +617,15 @@ func (f *interactiveFiller) Fill(v *dynamicpb.Message) error {
 	return f.fillFunc(v)
 }
 
-func CallRPCInteractively(ctx context.Context, w io.Writer, rpcName string, digManually, bytesAsBase64, bytesAsQuotedLiterals, bytesFromFile, rerunPrevious, addRepeatedManually bool) error {
-	return dm.CallRPCInteractively(ctx, w, rpcName, digManually, bytesAsBase64, bytesAsQuotedLiterals, bytesFromFile, rerunPrevious, addRepeatedManually)
+// CallRPCInteractively is the same as CallRPC, but the request is filled interactively.
+// If timeout is 0, the default timeout is used. If compression is empty, the default compressor is used.
+// If tmpl is not nil, each input is rendered by the template.
+func CallRPCInteractively(ctx context.Context, w io.Writer, rpcName string, digManually, bytesAsBase64, bytesAsQuotedLiterals, bytesFromFile, rerunPrevious, keepTiming, addRepeatedManually bool, timeout time.Duration, compression string, tmpl *fill.Template) error {
+	return dm.CallRPCInteractively(ctx, w, rpcName, digManually, bytesAsBase64, bytesAsQuotedLiterals, bytesFromFile, rerunPrevious, keepTiming, addRepeatedManually, timeout, compression, tmpl)
 }
 
-func (m *dependencyManager) CallRPCInteractively(ctx context.Context, w io.Writer, rpcName string, digManually, bytesAsBase64, bytesAsQuotedLiterals, bytesFromFile, rerunPrevious, addRepeatedManually bool) error {
-	return m.CallRPC(ctx, w, rpcName, rerunPrevious, &interactiveFiller{
+func (m *dependencyManager) CallRPCInteractively(ctx context.Context, w io.Writer, rpcName string, digManually, bytesAsBase64, bytesAsQuotedLiterals, bytesFromFile, rerunPrevious, keepTiming, addRepeatedManually bool, timeout time.Duration, compression string, tmpl *fill.Template) error {
+	return m.CallRPC(ctx, w, rpcName, rerunPrevious, keepTiming, &interactiveFiller{
 		fillFunc: func(v *dynamicpb.Message) error {
 			return m.interactiveFiller.Fill(v, fill.InteractiveFillerOpts{
 				DigManually:           digManually,
@@ -495,9 +633,53 @@ func (m *dependencyManager) CallRPCInteractively(ctx context.Context, w io.Write
 				BytesAsQuotedLiterals: bytesAsQuotedLiterals,
 				BytesFromFile:         bytesFromFile,
 				AddRepeatedManually:   addRepeatedManually,
+				Template:              tmpl,
 			})
 		},
-	})
+	}, timeout, compression)
+}
+
+// CallRPCWithEditor calls the RPC with requests written in an editor. edit receives a JSON skeleton of the request
+// and returns the edited text. If previous requests of the method exist, the skeleton is prefilled with them.
+// For client and bidi streaming RPCs, each JSON object in the edited text is sent as a message.
+func CallRPCWithEditor(ctx context.Context, w io.Writer, rpcName string, edit func(text string) (string, error), timeout time.Duration, compression string) error {
+	return dm.CallRPCWithEditor(ctx, w, rpcName, edit, timeout, compression)
+}
+
+func (m *dependencyManager) CallRPCWithEditor(ctx context.Context, w io.Writer, rpcName string, edit func(text string) (string, error), timeout time.Duration, compression string) error {
+	rpc, err := m.findRPC(rpcName)
+	if err != nil {
+		return err
+	}
+
+	// The skeleton is not prefilled if there are no previous requests.
+	previous, _ := m.getPreviousRPCRequests(rpc)
+	var skeletons []string
+	for _, r := range previous {
+		req := dynamicpb.NewMessage(rpc.Input())
+		if err := proto.Unmarshal(r.payload, req); err != nil {
+			return errors.Wrapf(err, "failed to unmarshal the previous request for method: %s", rpc.FullName())
+		}
+		s, err := fill.JSONSkeleton(rpc.Input(), req)
+		if err != nil {
+			return err
+		}
+		skeletons = append(skeletons, s)
+	}
+	if len(skeletons) == 0 {
+		s, err := fill.JSONSkeleton(rpc.Input(), nil)
+		if err != nil {
+			return err
+		}
+		skeletons = append(skeletons, s)
+	}
+
+	text, err := edit(strings.Join(skeletons, "\n"))
+	if err != nil {
+		return err
+	}
+	filler := fill.NewSilentFiller(strings.NewReader(fill.StripJSONComments(text)))
+	return m.CallRPC(ctx, w, string(rpc.FullName()), false, false, filler, timeout, compression)
 }
 
 func handleGRPCResponseError(err error) (*status.Status, error) {
@@ -1,13 +1,21 @@
 package usecase
 
 import (
+	"context"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
-	"google.golang.org/protobuf/proto"
+	"github.com/ktr0731/evans/fill"
+	"github.com/ktr0731/evans/format"
+	"github.com/ktr0731/evans/format/curl"
+	"github.com/ktr0731/evans/grpc"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
-func TestGetPreviousRPCRequest(t *testing.T) {
+func TestGetPreviousRPCRequests(t *testing.T) {
 	cases := map[string]struct {
 		expectedError string
 		method        protoreflect.MethodDescriptor
@@ -17,11 +25,6 @@ func TestGetPreviousRPCRequest(t *testing.T) {
 			method:        getStubMethod(false),
 			expectedError: "no previous request exists for method: TestRPC, please issue a normal request",
 		},
-		"previous request is client streaming": {
-			rpcCallState:  map[rpcIdentifier]callState{"TestRPC": {}},
-			method:        getStubMethod(true),
-			expectedError: "cannot rerun previous method: TestRPC as client/bidi streaming RPCs are not supported",
-		},
 		"previous request bytes are nil": {
 			rpcCallState:  map[rpcIdentifier]callState{"TestRPC": {}},
 			method:        getStubMethod(false),
@@ -36,8 +39,7 @@ func TestGetPreviousRPCRequest(t *testing.T) {
 					rpcCallState: c.rpcCallState,
 				},
 			}
-			var req proto.Message
-			err := d.getPreviousRPCRequest(c.method, req)
+			_, err := d.getPreviousRPCRequests(c.method)
 			if err == nil || err.Error() != c.expectedError {
 				t.Errorf("expected error %s, but got %s", c.expectedError, err)
 			}
@@ -45,6 +47,139 @@ func TestGetPreviousRPCRequest(t *testing.T) {
 	}
 }
 
+// deadlineClient is a grpc.Client which records the outgoing metadata and the deadline of Invoke.
+type deadlineClient struct {
+	grpc.Client
+
+	md          metadata.MD
+	hasDeadline bool
+	timeout     time.Duration
+}
+
+func (c *deadlineClient) Invoke(ctx context.Context, fqrn string, req, res interface{}) (metadata.MD, metadata.MD, error) {
+	c.md, _ = metadata.FromOutgoingContext(ctx)
+	var deadline time.Time
+	deadline, c.hasDeadline = ctx.Deadline()
+	c.timeout = time.Until(deadline)
+	return c.Client.Invoke(ctx, fqrn, req, res)
+}
+
+func TestCallRPC_timeout(t *testing.T) {
+	_, client := newHealthServer(t)
+
+	cases := map[string]struct {
+		header  string
+		timeout time.Duration
+
+		hasDeadline bool
+		minTimeout  time.Duration
+		hasErr      bool
+	}{
+		"no timeout":                   {},
+		"grpc-timeout header":          {header: "10S", hasDeadline: true, minTimeout: 9 * time.Second},
+		"timeout overrides the header": {header: "1S", timeout: time.Minute, hasDeadline: true, minTimeout: 59 * time.Second},
+		"negative timeout":             {timeout: -time.Second, hasErr: true},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			defer Clear()
+			dc := &deadlineClient{Client: client}
+			Inject(Dependencies{
+				GRPCClient:        dc,
+				DescSource:        healthDescSource{},
+				ResponseFormatter: format.NewResponseFormatter(curl.NewResponseFormatter(io.Discard, false), false),
+			})
+			if err := UsePackage("grpc.health.v1"); err != nil {
+				t.Fatalf("UsePackage must not return an error, but got '%s'", err)
+			}
+			if err := UseService("Health"); err != nil {
+				t.Fatalf("UseService must not return an error, but got '%s'", err)
+			}
+			if c.header != "" {
+				// Headers are held by the client which is shared between cases.
+				AddHeader("grpc-timeout", c.header)
+				defer RemoveHeader("grpc-timeout")
+			}
+
+			err := dm.CallRPC(context.Background(), io.Discard, "Check", false, false, fill.NewSilentFiller(strings.NewReader("{}")), c.timeout, "")
+			if c.hasErr {
+				if err == nil {
+					t.Fatal("CallRPC must return an error, but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CallRPC must not return an error, but got '%s'", err)
+			}
+			if v := dc.md.Get("grpc-timeout"); len(v) != 0 {
+				t.Errorf("grpc-timeout header must not be sent, but got %v", v)
+			}
+			if dc.hasDeadline != c.hasDeadline {
+				t.Fatalf("expected hasDeadline %t, but got %t", c.hasDeadline, dc.hasDeadline)
+			}
+			if c.hasDeadline && dc.timeout < c.minTimeout {
+				t.Errorf("expected the timeout is longer than %s, but got %s", c.minTimeout, dc.timeout)
+			}
+		})
+	}
+}
+
+func TestGRPCTimeoutHeader(t *testing.T) {
+	_, client := newHealthServer(t)
+
+	cases := map[string]func() error{
+		"CheckHealth": func() error {
+			_, err := CheckHealth(context.Background(), "")
+			return err
+		},
+		"Bench": func() error {
+			_, err := Bench(context.Background(), "Check", &BenchOption{Total: 1})
+			return err
+		},
+		"Batch": func() error {
+			return Batch(context.Background(), "Check", &BatchOption{}, func(*BatchResult) error { return nil })
+		},
+		"Replay": func() error {
+			rec := `{"method": "grpc.health.v1.Health.Check", "requestHeader": {"grpc-timeout": ["1S"]}, "requests": [{"message": {}}]}`
+			return Replay(context.Background(), strings.NewReader(rec), func(*ReplayResult) error { return nil })
+		},
+	}
+	for name, f := range cases {
+		f := f
+		t.Run(name, func(t *testing.T) {
+			defer Clear()
+			dc := &deadlineClient{Client: client}
+			Inject(Dependencies{
+				GRPCClient: dc,
+				DescSource: healthDescSource{},
+				Filler:     fill.NewSilentFiller(strings.NewReader("{}")),
+			})
+			if err := UsePackage("grpc.health.v1"); err != nil {
+				t.Fatalf("UsePackage must not return an error, but got '%s'", err)
+			}
+			if err := UseService("Health"); err != nil {
+				t.Fatalf("UseService must not return an error, but got '%s'", err)
+			}
+			AddHeader("grpc-timeout", "10S")
+			defer RemoveHeader("grpc-timeout")
+
+			if err := f(); err != nil {
+				t.Fatalf("must not return an error, but got '%s'", err)
+			}
+			if v := dc.md.Get("grpc-timeout"); len(v) != 0 {
+				t.Errorf("grpc-timeout header must not be sent, but got %v", v)
+			}
+			if !dc.hasDeadline {
+				t.Fatal("the grpc-timeout header must set the deadline")
+			}
+			if dc.timeout < 9*time.Second {
+				t.Errorf("expected the timeout is longer than 9s, but got %s", dc.timeout)
+			}
+		})
+	}
+}
+
 type stubMethod struct {
 	protoreflect.MethodDescriptor
 
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FormatCredentials formats the active credentials provider.
+func FormatCredentials() (string, error) {
+	return dm.FormatCredentials()
+}
+func (m *dependencyManager) FormatCredentials() (string, error) {
+	type credentials struct {
+		Provider string `json:"provider"`
+		Source   string `json:"source"`
+		Expiry   string `json:"expiry"`
+	}
+	var s struct {
+		Credentials []credentials `json:"credentials"`
+	}
+	if p := m.credentials; p != nil {
+		expiry := "-"
+		if t := p.Expiry(); !t.IsZero() {
+			expiry = t.Format(time.RFC3339)
+		}
+		s.Credentials = append(s.Credentials, credentials{p.Name(), p.Source(), expiry})
+	}
+	out, err := m.resourcePresenter.Format(s)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to format credentials by presenter")
+	}
+	return out, nil
+}
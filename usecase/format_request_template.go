@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"bytes"
+
+	"github.com/ktr0731/evans/fill"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"gopkg.in/yaml.v3"
+)
+
+// FormatRequestTemplate formats an example request of the method fqmn. format is one of "json" or "yaml".
+// If full is false, the request has only the zero values of the top-level fields. Otherwise, nested messages are
+// filled with representative values until depth levels. See fill.ExampleMessage for details.
+func FormatRequestTemplate(fqmn string, full bool, depth int, format string) (string, error) {
+	return dm.FormatRequestTemplate(fqmn, full, depth, format)
+}
+func (m *dependencyManager) FormatRequestTemplate(fqmn string, full bool, depth int, format string) (string, error) {
+	rpc, err := m.findMethod(fqmn)
+	if err != nil {
+		return "", err
+	}
+
+	req := dynamicpb.NewMessage(rpc.Input())
+	if full {
+		req = fill.ExampleMessage(rpc.Input(), depth)
+	}
+	b, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to marshal the template of %s", rpc.Input().FullName())
+	}
+
+	switch format {
+	case "json":
+		out, err := indentJSON(b)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to format the template as JSON")
+		}
+		return out, nil
+	case "yaml":
+		// JSON is also YAML. The field order is kept by decoding it as a node.
+		var node yaml.Node
+		if err := yaml.Unmarshal(b, &node); err != nil {
+			return "", errors.Wrap(err, "failed to format the template as YAML")
+		}
+		resetYAMLStyle(&node)
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return "", errors.Wrap(err, "failed to format the template as YAML")
+		}
+		if err := enc.Close(); err != nil {
+			return "", errors.Wrap(err, "failed to format the template as YAML")
+		}
+		return buf.String(), nil
+	default:
+		return "", errors.Errorf("unknown format '%s'", format)
+	}
+}
+
+// resetYAMLStyle resets the flow and quoted styles which n has because it was decoded from JSON.
+// Empty collections keep the flow style because they cannot be written in the block style.
+func resetYAMLStyle(n *yaml.Node) {
+	if len(n.Content) != 0 || n.Kind == yaml.ScalarNode {
+		n.Style = 0
+	}
+	for _, c := range n.Content {
+		resetYAMLStyle(c)
+	}
+}
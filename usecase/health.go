@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	gogrpc "google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// healthServiceName is the fully-qualified service name of the gRPC health checking protocol.
+// Its descriptors are bundled in the binary, so health checking doesn't require proto files or gRPC reflection.
+const healthServiceName = "grpc.health.v1.Health"
+
+// CheckHealth checks the serving status of the service by calling grpc.health.v1.Health.Check.
+// If service is empty, it checks the overall health of the server.
+// Headers, the default timeout and the default compressor are applied as well as CallRPC.
+func CheckHealth(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	return dm.CheckHealth(ctx, service)
+}
+func (m *dependencyManager) CheckHealth(ctx context.Context, service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+	opts, err := m.resolveCallOptions(0, "")
+	if err != nil {
+		return healthpb.HealthCheckResponse_UNKNOWN, err
+	}
+	ctx, cancel := m.newOutgoingContext(ctx, nil, opts)
+	defer cancel()
+
+	var res healthpb.HealthCheckResponse
+	_, _, err = m.gRPCClient.Invoke(ctx, healthServiceName+".Check", &healthpb.HealthCheckRequest{Service: service}, &res)
+	if err != nil {
+		return healthpb.HealthCheckResponse_UNKNOWN, handleHealthError(err)
+	}
+	return res.GetStatus(), nil
+}
+
+// WatchHealth watches the serving status of the service by calling grpc.health.v1.Health.Watch.
+// f is called each time the server sends a new status. WatchHealth returns nil if ctx is canceled or
+// the server closes the stream. If f returns an error, WatchHealth stops watching and returns it.
+func WatchHealth(ctx context.Context, service string, f func(healthpb.HealthCheckResponse_ServingStatus) error) error {
+	return dm.WatchHealth(ctx, service, f)
+}
+func (m *dependencyManager) WatchHealth(ctx context.Context, service string, f func(healthpb.HealthCheckResponse_ServingStatus) error) error {
+	// The stream has no deadline because it watches the status until ctx is canceled.
+	ctx, cancel := m.newOutgoingContext(ctx, nil, &callOptions{compression: m.state.defaultCompression})
+	defer cancel()
+
+	streamDesc := &gogrpc.StreamDesc{StreamName: "Watch", ServerStreams: true}
+	stream, err := m.gRPCClient.NewServerStream(ctx, streamDesc, healthServiceName+".Watch")
+	if err != nil {
+		return errors.Wrap(err, "failed to create a new server stream for RPC 'Watch'")
+	}
+	if err := stream.Send(&healthpb.HealthCheckRequest{Service: service}); err != nil {
+		return handleHealthError(err)
+	}
+	for {
+		var res healthpb.HealthCheckResponse
+		err := stream.Receive(&res)
+		if errors.Is(err, io.EOF) || errors.Is(ctx.Err(), context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			return handleHealthError(err)
+		}
+		if err := f(res.GetStatus()); err != nil {
+			return err
+		}
+	}
+}
+
+// handleHealthError converts err to gRPCError if it is a gRPC status.
+func handleHealthError(err error) error {
+	if stat, ok := status.FromError(errors.Cause(err)); ok {
+		return &gRPCError{stat}
+	}
+	return errors.Wrap(err, "failed to check health")
+}
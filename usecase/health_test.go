@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/ktr0731/evans/grpc"
+	"github.com/pkg/errors"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func newHealthServer(t *testing.T) (*health.Server, grpc.Client) {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen a TCP port: %s", err)
+	}
+	srv := gogrpc.NewServer()
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(srv, hs)
+	go srv.Serve(l) //nolint:errcheck
+	t.Cleanup(srv.Stop)
+
+	client, err := grpc.NewClient(l.Addr().String(), "", false, false, "", "", "", nil)
+	if err != nil {
+		t.Fatalf("grpc.NewClient must not return an error, but got '%s'", err)
+	}
+	t.Cleanup(func() { client.Close(context.Background()) })
+	return hs, client
+}
+
+func TestCheckHealth(t *testing.T) {
+	hs, client := newHealthServer(t)
+	hs.SetServingStatus("api.Example", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	defer Clear()
+	Inject(Dependencies{GRPCClient: client})
+
+	cases := map[string]struct {
+		service string
+
+		expected healthpb.HealthCheckResponse_ServingStatus
+		code     codes.Code
+	}{
+		"server":          {expected: healthpb.HealthCheckResponse_SERVING},
+		"service":         {service: "api.Example", expected: healthpb.HealthCheckResponse_NOT_SERVING},
+		"unknown service": {service: "api.Unknown", code: codes.NotFound},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			actual, err := CheckHealth(context.Background(), c.service)
+			if c.code != codes.OK {
+				var gerr *gRPCError
+				if !errors.As(err, &gerr) || gerr.Status.Code() != c.code {
+					t.Fatalf("CheckHealth must return a gRPC error with %s, but got '%v'", c.code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckHealth must not return an error, but got '%s'", err)
+			}
+			if actual != c.expected {
+				t.Errorf("expected %s, but got %s", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestWatchHealth(t *testing.T) {
+	hs, client := newHealthServer(t)
+	hs.SetServingStatus("api.Example", healthpb.HealthCheckResponse_SERVING)
+
+	defer Clear()
+	Inject(Dependencies{GRPCClient: client})
+
+	errStop := errors.New("stop")
+	var actual []healthpb.HealthCheckResponse_ServingStatus
+	err := WatchHealth(context.Background(), "api.Example", func(s healthpb.HealthCheckResponse_ServingStatus) error {
+		actual = append(actual, s)
+		if len(actual) == 1 {
+			hs.SetServingStatus("api.Example", healthpb.HealthCheckResponse_NOT_SERVING)
+			return nil
+		}
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("WatchHealth must return the error returned from f, but got '%v'", err)
+	}
+	expected := []healthpb.HealthCheckResponse_ServingStatus{
+		healthpb.HealthCheckResponse_SERVING,
+		healthpb.HealthCheckResponse_NOT_SERVING,
+	}
+	if len(actual) != len(expected) || actual[0] != expected[0] || actual[1] != expected[1] {
+		t.Errorf("expected %v, but got %v", expected, actual)
+	}
+}
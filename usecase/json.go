@@ -0,0 +1,16 @@
+package usecase
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// indentJSON indents b which is formatted by protojson.
+// protojson doesn't guarantee the stable output, so it is indented by encoding/json.
+func indentJSON(b []byte) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, b, "", "  "); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"sort"
+	"time"
+)
+
+// PreviousRequest is the requests of the last call of a method. They are resent by call --repeat.
+type PreviousRequest struct {
+	// Method is the fully-qualified method name such as "api.Example.Unary".
+	Method string
+	// Messages are the requests in the order they were sent. Unary and server streaming RPCs have only one message.
+	Messages []*PreviousMessage
+}
+
+// PreviousMessage is a request message of the last call.
+type PreviousMessage struct {
+	// Payload is the message serialized in the protobuf wire format.
+	Payload []byte
+	// Interval is the time from the previous message. It is 0 for the first message.
+	Interval time.Duration
+}
+
+// Size returns the total size of the payloads.
+func (r *PreviousRequest) Size() int {
+	var n int
+	for _, m := range r.Messages {
+		n += len(m.Payload)
+	}
+	return n
+}
+
+// ListPreviousRequests returns the requests of the last call of each method, sorted by the method name.
+func ListPreviousRequests() []*PreviousRequest {
+	return dm.ListPreviousRequests()
+}
+func (m *dependencyManager) ListPreviousRequests() []*PreviousRequest {
+	reqs := make([]*PreviousRequest, 0, len(m.state.rpcCallState))
+	for id, s := range m.state.rpcCallState {
+		req := &PreviousRequest{Method: string(id)}
+		for _, r := range s.requests {
+			req.Messages = append(req.Messages, &PreviousMessage{Payload: r.payload, Interval: r.interval})
+		}
+		reqs = append(reqs, req)
+	}
+	sort.Slice(reqs, func(i, j int) bool { return reqs[i].Method < reqs[j].Method })
+	return reqs
+}
+
+// SetPreviousRequests replaces the requests of the last calls by reqs. It is used to restore the requests saved in
+// the previous session.
+func SetPreviousRequests(reqs []*PreviousRequest) {
+	dm.SetPreviousRequests(reqs)
+}
+func (m *dependencyManager) SetPreviousRequests(reqs []*PreviousRequest) {
+	m.state.rpcCallState = make(map[rpcIdentifier]callState, len(reqs))
+	for _, req := range reqs {
+		s := callState{requests: make([]*callRequest, 0, len(req.Messages))}
+		for _, msg := range req.Messages {
+			s.requests = append(s.requests, &callRequest{payload: msg.Payload, interval: msg.Interval})
+		}
+		m.state.rpcCallState[rpcIdentifier(req.Method)] = s
+	}
+}
+
+// ClearPreviousRequests removes the requests of all last calls. It returns the number of removed methods.
+func ClearPreviousRequests() int {
+	return dm.ClearPreviousRequests()
+}
+func (m *dependencyManager) ClearPreviousRequests() int {
+	n := len(m.state.rpcCallState)
+	m.state.rpcCallState = nil
+	return n
+}
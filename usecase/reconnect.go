@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"github.com/ktr0731/evans/grpc"
+	"github.com/ktr0731/evans/logger"
+	"github.com/ktr0731/evans/proto"
+)
+
+// Reconnect replaces the gRPC client and the descriptor source with new ones.
+// Unlike Inject, the other states such as headers and previous requests are kept.
+// The selected package and service are also kept if descSource has them. Otherwise, they are unselected.
+// The caller must close the previous client.
+func Reconnect(client grpc.Client, descSource proto.DescriptorSource) {
+	dm.Reconnect(client, descSource)
+}
+
+func (m *dependencyManager) Reconnect(client grpc.Client, descSource proto.DescriptorSource) {
+	for k, v := range m.gRPCClient.Header() {
+		for _, vv := range v {
+			if err := client.Header().Add(k, vv); err != nil {
+				logger.Printf("failed to add a header %s=%s: %s", k, vv, err)
+			}
+		}
+	}
+	m.gRPCClient = client
+	m.descSource = descSource
+
+	pkg, svc := m.state.selectedPackage, m.state.selectedService
+	m.state.selectedPackage, m.state.selectedService = "", ""
+	if pkg == "" {
+		return
+	}
+	if err := m.UsePackage(pkg); err != nil {
+		logger.Printf("package '%s' is unselected because the new server doesn't have it", pkg)
+		return
+	}
+	if svc != "" {
+		if err := m.UseService(svc); err != nil {
+			logger.Printf("service '%s' is unselected because the new server doesn't have it", svc)
+		}
+	}
+}
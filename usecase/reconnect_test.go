@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ktr0731/evans/grpc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+type servicesDescSource []string
+
+func (s servicesDescSource) ListServices() ([]string, error) { return s, nil }
+
+func (s servicesDescSource) FindSymbol(string) (protoreflect.Descriptor, error) {
+	return nil, ErrUnknownSymbol
+}
+
+func TestReconnect(t *testing.T) {
+	cases := map[string]struct {
+		services []string
+
+		expectedDSN string
+	}{
+		"the new server has the selected service": {services: []string{"api.Example", "api.Other"}, expectedDSN: "api.Example"},
+		"the new server has the selected package": {services: []string{"api.Other"}, expectedDSN: "api"},
+		"the new server has nothing":              {services: []string{"other.Example"}, expectedDSN: ""},
+	}
+	for name, c := range cases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			defer Clear()
+			newClient := func() grpc.Client {
+				client, err := grpc.NewClient("", "", false, false, "", "", "", nil)
+				if err != nil {
+					t.Fatalf("grpc.NewClient must not return an error, but got '%s'", err)
+				}
+				return client
+			}
+			Inject(Dependencies{GRPCClient: newClient(), DescSource: servicesDescSource{"api.Example"}})
+			AddHeader("kumiko", "oumae")
+			if err := UsePackage("api"); err != nil {
+				t.Fatalf("UsePackage must not return an error, but got '%s'", err)
+			}
+			if err := UseService("Example"); err != nil {
+				t.Fatalf("UseService must not return an error, but got '%s'", err)
+			}
+
+			Reconnect(newClient(), servicesDescSource(c.services))
+
+			if dsn := GetDomainSourceName(); dsn != c.expectedDSN {
+				t.Errorf("expected '%s', but got '%s'", c.expectedDSN, dsn)
+			}
+			if diff := cmp.Diff(grpc.Headers{"kumiko": []string{"oumae"}}, ListHeaders()); diff != "" {
+				t.Errorf("headers must be kept:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ktr0731/evans/logger"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Record is an entry of a capture file. A capture file is a JSON Lines file that has a record for each call.
+type Record struct {
+	// Method is the fully-qualified method name such as "api.Example.Unary".
+	Method string `json:"method"`
+	// StartedAt is the time when the call started.
+	StartedAt time.Time `json:"startedAt"`
+	// Elapsed is the time from the start to the end of the call.
+	Elapsed time.Duration `json:"elapsed"`
+
+	// RequestHeader is the headers sent to the server.
+	RequestHeader metadata.MD `json:"requestHeader,omitempty"`
+	// Requests are the request messages. Client and bidi streaming RPCs have all frames sent to the server.
+	Requests []*RecordedMessage `json:"requests"`
+
+	ResponseHeader metadata.MD `json:"responseHeader,omitempty"`
+	// Responses are the response messages. Server and bidi streaming RPCs have all frames received from the server.
+	Responses []*RecordedMessage `json:"responses"`
+	Trailer   metadata.MD        `json:"trailer,omitempty"`
+	Status    *RecordedStatus    `json:"status"`
+}
+
+// RecordedMessage is a message of a call.
+type RecordedMessage struct {
+	// Message is the message formatted as JSON. Fields with default values are also included.
+	Message json.RawMessage `json:"message"`
+	// Elapsed is the time from the start of the call when the message was sent or received.
+	Elapsed time.Duration `json:"elapsed"`
+}
+
+// RecordedStatus is the status of a call.
+type RecordedStatus struct {
+	// Code is the status code name such as "OK".
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func newRecordedStatus(stat *status.Status) *RecordedStatus {
+	return &RecordedStatus{Code: stat.Code().String(), Message: stat.Message()}
+}
+
+// SetRecorder starts recording all calls to w. Each call is written as a Record line after the call is finished.
+// Recording is stopped if w is nil.
+func SetRecorder(w io.Writer) {
+	dm.SetRecorder(w)
+}
+func (m *dependencyManager) SetRecorder(w io.Writer) {
+	if w == nil {
+		m.state.recorder = nil
+		return
+	}
+	m.state.recorder = &recorder{enc: json.NewEncoder(w)}
+}
+
+// SetCallObserver sets f which is called with the record of each call after the call is finished.
+// It is unset if f is nil.
+func SetCallObserver(f func(*Record)) {
+	dm.SetCallObserver(f)
+}
+func (m *dependencyManager) SetCallObserver(f func(*Record)) {
+	m.state.callObserver = f
+}
+
+// recorder writes records to the capture file.
+type recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (r *recorder) write(rec *Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(rec); err != nil {
+		logger.Printf("failed to write a record: %s", err)
+	}
+}
+
+// newRecording returns a new recording for the call of method.
+// It returns nil if neither the recorder nor the call observer is set.
+func (m *dependencyManager) newRecording(method string) *recording {
+	if m.state.recorder == nil && m.state.callObserver == nil {
+		return nil
+	}
+	return &recording{r: m.state.recorder, observer: m.state.callObserver, rec: &Record{Method: method}}
+}
+
+// recording records a call. All methods are no-op if the receiver is nil so that callers don't need to check
+// whether recording is enabled.
+type recording struct {
+	r        *recorder
+	observer func(*Record)
+
+	mu  sync.Mutex
+	rec *Record
+}
+
+// start marks the start of the call which has header as the request headers.
+func (r *recording) start(t time.Time, header metadata.MD) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.StartedAt = t
+	r.rec.RequestHeader = header.Copy()
+}
+
+func (r *recording) addRequest(req proto.Message) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.Requests = append(r.rec.Requests, r.newMessage(req))
+}
+
+func (r *recording) setHeader(header metadata.MD) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.ResponseHeader = header
+}
+
+func (r *recording) addResponse(res interface{}) {
+	if r == nil {
+		return
+	}
+	msg, ok := res.(proto.Message)
+	if !ok || msg == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.Responses = append(r.rec.Responses, r.newMessage(msg))
+}
+
+func (r *recording) setTrailer(stat *status.Status, trailer metadata.MD) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rec.Status = newRecordedStatus(stat)
+	r.rec.Trailer = trailer
+}
+
+// newMessage converts msg to a RecordedMessage. r.mu must be held.
+// Messages sent before the start, such as the request of unary RPCs, are recorded as they are sent at the start.
+func (r *recording) newMessage(msg proto.Message) *RecordedMessage {
+	b, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(msg)
+	if err != nil {
+		logger.Printf("failed to marshal a message for recording: %s", err)
+		b = []byte("null")
+	}
+	var elapsed time.Duration
+	if !r.rec.StartedAt.IsZero() {
+		elapsed = time.Since(r.rec.StartedAt)
+	}
+	return &RecordedMessage{Message: b, Elapsed: elapsed}
+}
+
+// finish writes the record to the capture file and passes it to the call observer.
+// If the status isn't set by the trailer, it is converted from err.
+// Calls which failed before the start, such as failures of inputting requests, are not recorded.
+func (r *recording) finish(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rec.StartedAt.IsZero() {
+		return
+	}
+	r.rec.Elapsed = time.Since(r.rec.StartedAt)
+	if r.rec.Status == nil {
+		r.rec.Status = newRecordedStatus(errorStatus(err))
+	}
+	if r.rec.Requests == nil {
+		r.rec.Requests = []*RecordedMessage{}
+	}
+	if r.rec.Responses == nil {
+		r.rec.Responses = []*RecordedMessage{}
+	}
+
+	if r.r != nil {
+		r.r.write(r.rec)
+	}
+	if r.observer != nil {
+		r.observer(r.rec)
+	}
+}
+
+// errorStatus converts err returned from RPC calls to a status. It returns OK if err is nil.
+func errorStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	var gerr *gRPCError
+	if errors.As(err, &gerr) {
+		return gerr.Status
+	}
+	return status.Convert(errors.Cause(err))
+}
@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// ReplayResult is the result of replaying a record.
+type ReplayResult struct {
+	// Record is the replayed record.
+	Record *Record
+	// Status and Responses are the actual status and responses.
+	Status    *RecordedStatus
+	Responses []json.RawMessage
+	// Diffs describe the differences between the record and the actual result. It is empty if there are no
+	// differences.
+	Diffs []string
+}
+
+// Replay reads records from r and re-issues each call against the current server, then f is called with the result.
+// The status and the response messages are compared with the record. Headers and trailers aren't compared because
+// they are usually different for each call.
+// The recorded request headers are sent with the headers added by AddHeader. The latter has priority.
+func Replay(ctx context.Context, r io.Reader, f func(*ReplayResult) error) error {
+	return dm.Replay(ctx, r, f)
+}
+func (m *dependencyManager) Replay(ctx context.Context, r io.Reader, f func(*ReplayResult) error) error {
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var rec Record
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to decode record #%d", i)
+		}
+		res, err := m.replay(ctx, &rec)
+		if err != nil {
+			return errors.Wrapf(err, "failed to replay record #%d (%s)", i, rec.Method)
+		}
+		if err := f(res); err != nil {
+			return err
+		}
+	}
+}
+
+func (m *dependencyManager) replay(ctx context.Context, rec *Record) (*ReplayResult, error) {
+	d, err := m.descSource.FindSymbol(rec.Method)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the RPC descriptor for: %s", rec.Method)
+	}
+	rpc, ok := d.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, errors.Errorf("'%s' is not a RPC", rec.Method)
+	}
+
+	unmarshal := func(b []byte, desc protoreflect.MessageDescriptor) (proto.Message, error) {
+		msg := dynamicpb.NewMessage(desc)
+		if err := protojson.Unmarshal(b, msg); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+	reqs := make([]proto.Message, 0, len(rec.Requests))
+	for _, r := range rec.Requests {
+		req, err := unmarshal(r.Message, rpc.Input())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal a recorded request")
+		}
+		reqs = append(reqs, req)
+	}
+	if len(reqs) == 0 && !rpc.IsStreamingClient() {
+		return nil, errors.New("the record has no requests")
+	}
+
+	opts, err := m.resolveCallOptions(0, "")
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := m.newOutgoingContext(ctx, rec.RequestHeader, opts)
+	defer cancel()
+
+	var ress []proto.Message
+	err = m.invokeAll(ctx, rpc, reqs, func(res proto.Message) { ress = append(ress, res) })
+	result := &ReplayResult{
+		Record: rec,
+		Status: newRecordedStatus(errorStatus(err)),
+	}
+	for _, res := range ress {
+		b, err := protojson.Marshal(res)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to marshal a response")
+		}
+		result.Responses = append(result.Responses, b)
+	}
+
+	if rec.Status == nil {
+		rec.Status = newRecordedStatus(errorStatus(nil))
+	}
+	if *rec.Status != *result.Status {
+		result.Diffs = append(result.Diffs, fmt.Sprintf("status: recorded %s, but got %s", formatRecordedStatus(rec.Status), formatRecordedStatus(result.Status)))
+	}
+	if len(rec.Responses) != len(ress) {
+		result.Diffs = append(result.Diffs, fmt.Sprintf("the number of responses: recorded %d, but got %d", len(rec.Responses), len(ress)))
+	}
+	for i := 0; i < len(rec.Responses) && i < len(ress); i++ {
+		expected, err := unmarshal(rec.Responses[i].Message, rpc.Output())
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal a recorded response")
+		}
+		if !proto.Equal(expected, ress[i]) {
+			result.Diffs = append(result.Diffs, fmt.Sprintf("response #%d: recorded %s, but got %s", i+1, rec.Responses[i].Message, result.Responses[i]))
+		}
+	}
+	return result, nil
+}
+
+func formatRecordedStatus(s *RecordedStatus) string {
+	if s.Message == "" {
+		return s.Code
+	}
+	return fmt.Sprintf("%s (%s)", s.Code, s.Message)
+}
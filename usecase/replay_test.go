@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ktr0731/evans/fill"
+	"github.com/ktr0731/evans/format"
+	"github.com/ktr0731/evans/format/curl"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	hs, client := newHealthServer(t)
+	hs.SetServingStatus("api.Example", healthpb.HealthCheckResponse_SERVING)
+
+	defer Clear()
+	Inject(Dependencies{
+		GRPCClient:        client,
+		DescSource:        healthDescSource{},
+		Filler:            fill.NewSilentFiller(strings.NewReader(`{"service": "api.Example"} {"service": "api.Unknown"}`)),
+		ResponseFormatter: format.NewResponseFormatter(curl.NewResponseFormatter(io.Discard, false), false),
+	})
+	if err := UsePackage("grpc.health.v1"); err != nil {
+		t.Fatalf("UsePackage must not return an error, but got '%s'", err)
+	}
+	if err := UseService("Health"); err != nil {
+		t.Fatalf("UseService must not return an error, but got '%s'", err)
+	}
+	AddHeader("foo", "bar")
+
+	var buf bytes.Buffer
+	SetRecorder(&buf)
+	if err := CallRPC(context.Background(), io.Discard, "Check"); err != nil {
+		t.Fatalf("CallRPC must not return an error, but got '%s'", err)
+	}
+	if err := CallRPC(context.Background(), io.Discard, "Check"); err == nil {
+		t.Fatalf("CallRPC must return an error, but got nil")
+	}
+	// The end of the input isn't recorded.
+	if err := CallRPC(context.Background(), io.Discard, "Check"); err == nil {
+		t.Fatalf("CallRPC must return an error, but got nil")
+	}
+	SetRecorder(nil)
+
+	capture := buf.String()
+	dec := json.NewDecoder(strings.NewReader(capture))
+	var recs []*Record
+	for dec.More() {
+		var rec Record
+		if err := dec.Decode(&rec); err != nil {
+			t.Fatalf("failed to decode a record: %s", err)
+		}
+		recs = append(recs, &rec)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("expected 2 records, but got %d:\n%s", len(recs), capture)
+	}
+	if rec := recs[0]; rec.Method != "grpc.health.v1.Health.Check" || rec.Status.Code != "OK" ||
+		len(rec.Requests) != 1 || len(rec.Responses) != 1 || rec.RequestHeader.Get("foo")[0] != "bar" {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+	if rec := recs[1]; rec.Status.Code != "NotFound" || len(rec.Responses) != 0 {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+
+	replay := func() [][]string {
+		var diffs [][]string
+		err := Replay(context.Background(), strings.NewReader(capture), func(res *ReplayResult) error {
+			diffs = append(diffs, res.Diffs)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Replay must not return an error, but got '%s'", err)
+		}
+		return diffs
+	}
+
+	if diffs := replay(); len(diffs) != 2 || len(diffs[0]) != 0 || len(diffs[1]) != 0 {
+		t.Errorf("the replayed calls must be the same as the records, but got %v", diffs)
+	}
+
+	hs.SetServingStatus("api.Example", healthpb.HealthCheckResponse_NOT_SERVING)
+	hs.SetServingStatus("api.Unknown", healthpb.HealthCheckResponse_SERVING)
+	diffs := replay()
+	if len(diffs) != 2 || len(diffs[0]) != 1 || len(diffs[1]) != 2 {
+		t.Fatalf("unexpected diffs: %v", diffs)
+	}
+	if !strings.HasPrefix(diffs[0][0], "response #1: ") {
+		t.Errorf("the response must be different, but got '%s'", diffs[0][0])
+	}
+	if !strings.HasPrefix(diffs[1][0], "status: recorded NotFound") {
+		t.Errorf("the status must be different, but got '%s'", diffs[1][0])
+	}
+}
@@ -3,6 +3,9 @@
 package usecase
 
 import (
+	"time"
+
+	"github.com/ktr0731/evans/auth"
 	"github.com/ktr0731/evans/fill"
 	"github.com/ktr0731/evans/format"
 	"github.com/ktr0731/evans/grpc"
@@ -33,6 +36,7 @@ type dependencyManager struct {
 	gRPCClient        grpc.Client
 	responseFormatter *format.ResponseFormatter
 	resourcePresenter present.Presenter
+	credentials       auth.Provider
 	state             state
 }
 
@@ -43,10 +47,29 @@ type state struct {
 	selectedPackage string // TODO: remove in v1.0.0.
 	selectedService string
 	rpcCallState    map[rpcIdentifier]callState
+	// defaultTimeout is used for RPCs called without a timeout. No timeout if it is 0.
+	defaultTimeout time.Duration
+	// defaultCompression is used for RPCs called without a compressor. No compression if it is empty.
+	defaultCompression string
+	// recorder records all calls to the capture file. Calls aren't recorded if it is nil.
+	recorder *recorder
+	// callObserver is called with the record of each call. It is nil if no one observes calls.
+	callObserver func(*Record)
+	// callHistory is the last calls in the order they were called.
+	callHistory []*callHistoryEntry
+	// callHistorySeq is the ID of the last call added to callHistory.
+	callHistorySeq int
 }
 
 type callState struct {
-	requestPayload []byte
+	// requests are the requests sent in the call in order. Unary and server streaming RPCs have only one request.
+	requests []*callRequest
+}
+
+type callRequest struct {
+	payload []byte
+	// interval is the time from the previous request. It is 0 for the first request.
+	interval time.Duration
 }
 
 type Dependencies struct {
@@ -56,6 +79,8 @@ type Dependencies struct {
 	GRPCClient        grpc.Client
 	ResponseFormatter *format.ResponseFormatter
 	ResourcePresenter present.Presenter
+	// Credentials is the active credentials provider. It is nil if no providers are used.
+	Credentials auth.Provider
 }
 
 // Inject corresponds an implementation to an interface type. Inject clears the previous states if it exists.
@@ -71,6 +96,7 @@ func (m *dependencyManager) Inject(d Dependencies) {
 		gRPCClient:        d.GRPCClient,
 		responseFormatter: d.ResponseFormatter,
 		resourcePresenter: d.ResourcePresenter,
+		credentials:       d.Credentials,
 
 		state: defaultState,
 	}
@@ -100,6 +126,9 @@ func (m *dependencyManager) InjectPartially(d Dependencies) {
 	if d.ResourcePresenter != nil {
 		m.resourcePresenter = d.ResourcePresenter
 	}
+	if d.Credentials != nil {
+		m.credentials = d.Credentials
+	}
 }
 
 // Clear clears all dependencies and states. Usually, it is used for unit testing.